@@ -1,7 +1,19 @@
 package main
 
-import run "github.com/Bitummit/booking_api/internal"
+import (
+	"fmt"
+	"os"
+
+	run "github.com/Bitummit/booking_api/internal"
+)
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := run.Migrate(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 	run.Run()
 }
@@ -0,0 +1,233 @@
+// Package problem writes RFC 7807 application/problem+json responses. Every error of the
+// REST api goes through it, so clients can rely on the stable Code of a problem.
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/Bitummit/booking_api/internal/service"
+	authclient "github.com/Bitummit/booking_api/internal/service/authClient"
+	"github.com/Bitummit/booking_api/internal/storage/postgresql"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-playground/validator/v10"
+)
+
+const ContentType = "application/problem+json"
+
+// Stable problem codes, clients match on them instead of the human readable detail.
+const (
+	CodeInternal = "internal_error"
+	CodeMalformedBody = "malformed_body"
+	CodeValidation = "validation_failed"
+	CodeInvalidParameter = "invalid_parameter"
+	CodeUnauthorized = "unauthorized"
+	CodeInvalidToken = "invalid_token"
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeForbidden = "forbidden"
+	CodeUserBlocked = "user_blocked"
+	CodeNotFound = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeUserNotFound = "user_not_found"
+	CodeNotManager = "not_manager"
+	CodeTagNotFound = "tag_not_found"
+	CodeCityNotFound = "city_not_found"
+	CodeCategoryNotInHotel = "category_not_in_hotel"
+	CodeBookingNotInHotel = "booking_not_in_hotel"
+	CodeAlreadyExists = "already_exists"
+	CodeInUse = "in_use"
+	CodeInsertionFailed = "insertion_failed"
+	CodeNoFreeRoom = "no_free_room"
+	CodeBookingChanged = "booking_changed"
+	CodeInvalidTransition = "invalid_transition"
+	CodeInvalidDates = "invalid_dates"
+	CodeCapacityExceeded = "capacity_exceeded"
+	CodeInvalidCursor = "invalid_cursor"
+	CodeInvalidRole = "invalid_role"
+	CodeWrongPassword = "wrong_password"
+	CodeNotSupported = "not_supported"
+	CodeAuthUnavailable = "auth_unavailable"
+)
+
+type Problem struct {
+	Type string `json:"type"`
+	Title string `json:"title"`
+	Status int `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code string `json:"code"`
+	RequestId string `json:"request_id,omitempty"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+type FieldError struct {
+	Field string `json:"field"`
+	Rule string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type mapping struct {
+	target error
+	status int
+	code string
+	detail string
+}
+
+// mappings are checked in order with errors.Is, more specific errors come first.
+var mappings = []mapping{
+	{service.ErrorUnauthorized, http.StatusUnauthorized, CodeUnauthorized, "authentication required"},
+	{authclient.ErrorInvalidToken, http.StatusUnauthorized, CodeInvalidToken, "invalid token"},
+	{service.ErrorPermissionDenied, http.StatusForbidden, CodeForbidden, "no enough permission"},
+	{authclient.ErrorPermissionDenied, http.StatusForbidden, CodeForbidden, "no enough permission"},
+	{authclient.ErrorInvalidPassword, http.StatusBadRequest, CodeWrongPassword, "wrong password"},
+	{authclient.ErrorNotSupported, http.StatusNotImplemented, CodeNotSupported, "not supported by auth service"},
+	{authclient.ErrorAlreadyExists, http.StatusConflict, CodeAlreadyExists, "user already exists"},
+	{authclient.ErrorUnavailable, http.StatusServiceUnavailable, CodeAuthUnavailable, "auth service unavailable"},
+	{service.ErrorCategoryNotInHotel, http.StatusNotFound, CodeCategoryNotInHotel, "no such room category in hotel"},
+	{service.ErrorBookingNotInHotel, http.StatusNotFound, CodeBookingNotInHotel, "no such booking in hotel"},
+	{service.ErrorInvalidDates, http.StatusBadRequest, CodeInvalidDates, "invalid stay dates"},
+	{service.ErrorCapacityExceeded, http.StatusBadRequest, CodeCapacityExceeded, "too many guests for this room category"},
+	{service.ErrorInvalidCursor, http.StatusBadRequest, CodeInvalidCursor, "invalid cursor"},
+	{service.ErrorInvalidRole, http.StatusBadRequest, CodeInvalidRole, "unknown role"},
+	{postgresql.ErrorUserNotExists, http.StatusNotFound, CodeUserNotFound, "no such user"},
+	{postgresql.ErrorNotManager, http.StatusUnprocessableEntity, CodeNotManager, "user must be a manager or an admin"},
+	{postgresql.ErrorTagNotExists, http.StatusUnprocessableEntity, CodeTagNotFound, "no such tag"},
+	{postgresql.ErrorCityNotExists, http.StatusUnprocessableEntity, CodeCityNotFound, "no such city"},
+	{postgresql.ErrorNotExists, http.StatusNotFound, CodeNotFound, "not found"},
+	{postgresql.ErrorExists, http.StatusConflict, CodeAlreadyExists, "already exists"},
+	{postgresql.ErrorInUse, http.StatusConflict, CodeInUse, "still referenced by other records"},
+	{postgresql.ErrorNoFreeRoom, http.StatusConflict, CodeNoFreeRoom, "no free room for these dates"},
+	{postgresql.ErrorStatusChanged, http.StatusConflict, CodeBookingChanged, "booking was changed by another request"},
+	{postgresql.ErrorInsertion, http.StatusBadRequest, CodeInsertionFailed, "insertion error"},
+}
+
+// Error writes the problem matching err. Unknown errors become a 500 without details,
+// the caller is expected to log them.
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	var transitionErr *service.TransitionError
+	if errors.As(err, &transitionErr) {
+		Write(w, r, http.StatusConflict, CodeInvalidTransition, transitionErr.Error())
+		return
+	}
+	for _, m := range mappings {
+		if errors.Is(err, m.target) {
+			Write(w, r, m.status, m.code, m.detail)
+			return
+		}
+	}
+	Write(w, r, http.StatusInternalServerError, CodeInternal, "")
+}
+
+func Write(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	write(w, r, Problem{
+		Status: status,
+		Code: code,
+		Detail: detail,
+	})
+}
+
+// MalformedBody is written when the request body can not be decoded.
+func MalformedBody(w http.ResponseWriter, r *http.Request) {
+	Write(w, r, http.StatusBadRequest, CodeMalformedBody, "request body is not valid json")
+}
+
+// InvalidParameter is written for a bad path or query parameter.
+func InvalidParameter(w http.ResponseWriter, r *http.Request, name, message string) {
+	write(w, r, Problem{
+		Status: http.StatusBadRequest,
+		Code: CodeInvalidParameter,
+		Detail: name + " " + message,
+		Errors: []FieldError{{Field: name, Rule: "format", Message: message}},
+	})
+}
+
+// Validation writes one field error per failed validator rule.
+func Validation(w http.ResponseWriter, r *http.Request, err error) {
+	p := Problem{
+		Status: http.StatusBadRequest,
+		Code: CodeValidation,
+		Detail: "request validation failed",
+	}
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		for _, fieldErr := range validationErrs {
+			p.Errors = append(p.Errors, FieldError{
+				Field: fieldPath(fieldErr),
+				Rule: fieldErr.Tag(),
+				Message: fieldMessage(fieldErr),
+			})
+		}
+	}
+	write(w, r, p)
+}
+
+func write(w http.ResponseWriter, r *http.Request, p Problem) {
+	p.Type = "about:blank"
+	p.Title = http.StatusText(p.Status)
+	p.Instance = r.URL.Path
+	p.RequestId = middleware.GetReqID(r.Context())
+
+	// render.JSON would set application/json, so the problem is encoded here
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// NewValidator reports json field names in validation errors.
+func NewValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			// query string requests name their parameters with a query tag
+			name = field.Tag.Get("query")
+		}
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	return v
+}
+
+// fieldPath drops the request struct name from the namespace, e.g. "tags[0]".
+func fieldPath(err validator.FieldError) string {
+	_, path, found := strings.Cut(err.Namespace(), ".")
+	if !found {
+		return err.Field()
+	}
+	return path
+}
+
+func fieldMessage(err validator.FieldError) string {
+	switch err.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		return "must be at least " + err.Param()
+	case "max", "lte":
+		return "must be at most " + err.Param()
+	case "gt":
+		return "must be greater than " + err.Param()
+	case "oneof":
+		return "must be one of: " + err.Param()
+	case "email":
+		return "must be an email"
+	case "numeric":
+		return "must be numeric"
+	case "datetime":
+		return "must have the format " + err.Param()
+	case "gtfield", "gtefield":
+		return "must be after " + err.Param()
+	case "nefield":
+		return "must differ from " + err.Param()
+	default:
+		return "failed on " + err.Tag()
+	}
+}
@@ -5,16 +5,19 @@ import "github.com/Bitummit/booking_api/internal/models"
 type (
 	Response struct{
 		Status string `json:"status"`
-		Error string `json:"error,omitempty"`
+	}
+	HealthResponse struct {
+		Status string `json:"status"`
+		AuthBreaker string `json:"auth_breaker"`
 	}
 	CreationResponse struct {
 		Id int64 `json:"id"`
 	}
 	CreateTagRequest struct{
-		Name string `json:"name"`
+		Name string `json:"name" validate:"required"`
 	}
 	CreateCityRequest struct{
-		Name string `json:"name"`
+		Name string `json:"name" validate:"required"`
 	}
 	ListCityResponse struct {
 		Cities []models.City `json:"cities"`
@@ -28,8 +31,91 @@ type (
 		City string 	`json:"city"`
 		Tags []string	`json:"tags"`
 	}
+	UpdateHotelRequest struct {
+		Name *string 	`json:"name,omitempty" validate:"omitempty,min=1,max=255"`
+		Desc *string 	`json:"desc,omitempty"`
+		City *string 	`json:"city,omitempty" validate:"omitempty,min=1"`
+		Tags *[]string 	`json:"tags,omitempty" validate:"omitempty,dive,required"`
+	}
+	TransferHotelRequest struct {
+		ManagerId int64 `json:"manager_id" validate:"required"`
+	}
+	TransferHotelResponse struct {
+		Transfer *models.HotelTransfer `json:"transfer"`
+	}
+	ListHotelTransfersResponse struct {
+		Transfers []models.HotelTransfer `json:"transfers"`
+	}
 	ListHotelsResponse struct {
-		Hotels []*models.Hotel `json: "hotels"`
+		Hotels []*models.Hotel `json:"hotels"`
+	}
+	SearchHotelsRequest struct {
+		City string 		`query:"city" validate:"omitempty,max=255"`
+		Tags []string 		`query:"tags" validate:"dive,required"`
+		TagsMode string 	`query:"tags_mode" validate:"omitempty,oneof=any all"`
+		Name string 		`query:"q" validate:"omitempty,max=255"`
+		PriceFrom float64 	`query:"price_from" validate:"gte=0"`
+		PriceTo float64 	`query:"price_to" validate:"gte=0"`
+		Capacity int64 		`query:"capacity" validate:"gte=0"`
+		Sort string 		`query:"sort" validate:"omitempty,oneof=id name price"`
+		Order string 		`query:"order" validate:"omitempty,oneof=asc desc"`
+		Limit int 			`query:"limit" validate:"gte=0,lte=100"`
+		Cursor string 		`query:"cursor"`
+	}
+	SearchHotelsResponse struct {
+		Hotels []*models.Hotel 	`json:"hotels"`
+		NextCursor string 		`json:"next_cursor,omitempty"`
+	}
+	RoomCategoryRequest struct {
+		Name string 	`json:"name" validate:"required"`
+		Price float64 	`json:"price" validate:"gt=0"`
+		Capacity int64 	`json:"capacity" validate:"gt=0"`
+		Desc string 	`json:"desc,omitempty"`
+		Size int64 		`json:"size" validate:"gt=0"`
+	}
+	ListRoomCategoriesResponse struct {
+		Categories []models.RoomCategory `json:"categories"`
+	}
+	AvailabilityRequest struct {
+		City string 	`query:"city" validate:"required,max=255"`
+		From string 	`query:"from" validate:"required,datetime=2006-01-02"`
+		To string 		`query:"to" validate:"required,datetime=2006-01-02"`
+		Guests int64 	`query:"guests" validate:"gte=0"`
+	}
+	HotelStayRequest struct {
+		From string 	`query:"from" validate:"required_with=To,omitempty,datetime=2006-01-02"`
+		To string 		`query:"to" validate:"required_with=From,omitempty,datetime=2006-01-02"`
+		Guests int64 	`query:"guests" validate:"gte=0"`
+	}
+	HotelResponse struct {
+		Hotel *models.Hotel 							`json:"hotel"`
+		Availability []models.CategoryAvailability 	`json:"availability,omitempty"`
+	}
+	AvailabilityResponse struct {
+		Hotels []models.HotelAvailability `json:"hotels"`
+	}
+	CreateRoomRequest struct {
+		Number string `json:"number" validate:"required,max=50"`
+	}
+	ListRoomsResponse struct {
+		Rooms []models.Room `json:"rooms"`
+	}
+	DeleteRoomResponse struct {
+		Status string 	`json:"status"`
+		Retired bool 	`json:"retired"`
+	}
+	CreateBookingRequest struct {
+		HotelId int64 		`json:"hotel_id" validate:"required"`
+		CategoryId int64 	`json:"category_id" validate:"required"`
+		EntryDate string 	`json:"entry_date" validate:"required,datetime=2006-01-02"`
+		LeaveDate string 	`json:"leave_date" validate:"required,datetime=2006-01-02"`
+		GuestsCount int64 	`json:"guests_count" validate:"gt=0"`
+	}
+	BookingResponse struct {
+		Booking *models.Booking `json:"booking"`
+	}
+	BookingStatusRequest struct {
+		Status string `json:"status" validate:"required,oneof=submitted checked_in closed cancelled no_show"`
 	}
 
 	RegistrationRequest struct {
@@ -51,15 +137,54 @@ type (
 		Token string `json:"access_token"`
 	}
 
+	UserResponse struct {
+		Id int64 `json:"id"`
+		Username string `json:"username"`
+		FirstName string `json:"first_name"`
+		LastName string `json:"last_name"`
+		Email string `json:"email"`
+		Birthday string `json:"birthday,omitempty"`
+		Role models.Role `json:"role"`
+		Blocked bool `json:"blocked"`
+	}
+	UpdateMeRequest struct {
+		FirstName *string `json:"first_name,omitempty" validate:"omitempty,max=255"`
+		LastName *string `json:"last_name,omitempty" validate:"omitempty,max=255"`
+		Email *string `json:"email,omitempty" validate:"omitempty,email"`
+		Birthday *string `json:"birthday,omitempty" validate:"omitempty,datetime=2006-01-02"`
+	}
+	ChangePasswordRequest struct {
+		OldPassword string `json:"old_password" validate:"required"`
+		NewPassword string `json:"new_password" validate:"required,min=8,nefield=OldPassword"`
+	}
+
 	UpdateUserRoleRequest struct {
-		Username string 	`json:"username"`
-		Role string 	`json:"role"`
+		Username string 	`json:"username" validate:"required"`
+		Role models.Role 	`json:"role" validate:"required,oneof=client manager admin"`
+	}
+	ChangeUserRoleRequest struct {
+		Role models.Role `json:"role" validate:"required,oneof=client manager admin"`
+	}
+	ListUsersResponse struct {
+		Users []UserResponse `json:"users"`
+	}
+	AuditResponse struct {
+		Entries []models.AuditEntry `json:"entries"`
 	}
 )
 
-func ErrorResponse(msg string) Response {
-	return Response{
-		Status: "ERROR",
-		Error: msg,
+func NewUserResponse(user *models.User) UserResponse {
+	resp := UserResponse{
+		Id: user.Id,
+		Username: user.Username,
+		FirstName: user.FirstName,
+		LastName: user.LastName,
+		Email: user.Email,
+		Role: user.Role,
+		Blocked: user.Blocked,
+	}
+	if !user.Birthday.IsZero() {
+		resp.Birthday = user.Birthday.Format("2006-01-02")
 	}
+	return resp
 }
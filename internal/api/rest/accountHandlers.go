@@ -0,0 +1,93 @@
+package rest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Bitummit/booking_api/internal/api"
+	"github.com/Bitummit/booking_api/internal/api/problem"
+	"github.com/Bitummit/booking_api/internal/middlewares"
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/Bitummit/booking_api/pkg/logger"
+	"github.com/go-chi/render"
+)
+
+func (s *HTTPServer) MeHandler(w http.ResponseWriter, r *http.Request) {
+	// RequireAuth guarantees the user
+	user, _ := models.UserFromContext(r.Context())
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.NewUserResponse(user))
+}
+
+func (s *HTTPServer) UpdateMeHandler(w http.ResponseWriter, r *http.Request) {
+	var req api.UpdateMeRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+
+	update := models.ProfileUpdate{
+		FirstName: req.FirstName,
+		LastName: req.LastName,
+		Email: req.Email,
+	}
+	if req.Birthday != nil {
+		// format is checked by the validator above
+		birthday, _ := time.Parse(time.DateOnly, *req.Birthday)
+		update.Birthday = &birthday
+	}
+
+	updated, err := s.HotelService.UpdateProfile(r.Context(), update)
+	if err != nil {
+		s.Log.Error("updating profile ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+	// the next request of the user reads the new profile from the auth service
+	s.AuthService.ForgetUser(updated.Username)
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.NewUserResponse(updated))
+}
+
+func (s *HTTPServer) RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
+	token, err := s.AuthService.RefreshToken(r.Context(), middlewares.BearerToken(r))
+	if err != nil {
+		s.Log.Error("refreshing token ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.LoginResponse{
+		Token: token,
+	})
+}
+
+func (s *HTTPServer) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.AuthService.Logout(r.Context(), middlewares.BearerToken(r)); err != nil {
+		s.Log.Error("logging out ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.Response{Status: "OK"})
+}
+
+func (s *HTTPServer) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var req api.ChangePasswordRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+
+	err := s.AuthService.ChangePassword(r.Context(), middlewares.BearerToken(r), req.OldPassword, req.NewPassword)
+	if err != nil {
+		s.Log.Error("changing password ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.Response{Status: "OK"})
+}
@@ -0,0 +1,79 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Bitummit/booking_api/internal/api"
+	"github.com/Bitummit/booking_api/internal/api/problem"
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/Bitummit/booking_api/pkg/logger"
+	"github.com/go-chi/render"
+)
+
+// ListAuditHandler filters by actor_id, entity_type, entity_id and an RFC 3339 from/to range,
+// newest entries first.
+func (s *HTTPServer) ListAuditHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.AuditFilter{
+		EntityType: query.Get("entity_type"),
+	}
+
+	ints := map[string]*int64{
+		"actor_id": &filter.ActorId,
+		"entity_id": &filter.EntityId,
+	}
+	for name, dst := range ints {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			problem.InvalidParameter(w, r, name, "is not int")
+			return
+		}
+		*dst = n
+	}
+	for name, dst := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			problem.InvalidParameter(w, r, name, "is not a non-negative int")
+			return
+		}
+		*dst = n
+	}
+	for name, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			problem.InvalidParameter(w, r, name, "is not an RFC 3339 time")
+			return
+		}
+		*dst = &t
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		problem.InvalidParameter(w, r, "from", "must be before to")
+		return
+	}
+
+	entries, err := s.HotelService.ListAudit(r.Context(), filter)
+	if err != nil {
+		s.Log.Error("listing audit ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.AuditResponse{
+		Entries: entries,
+	})
+}
@@ -1,28 +1,20 @@
 package rest
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/Bitummit/booking_api/internal/api"
+	"github.com/Bitummit/booking_api/internal/api/problem"
 	"github.com/Bitummit/booking_api/internal/models"
+	authclient "github.com/Bitummit/booking_api/internal/service/authClient"
 	"github.com/Bitummit/booking_api/pkg/logger"
 	"github.com/go-chi/render"
-	"github.com/go-playground/validator/v10"
 )
 
 func (s *HTTPServer) RegistrationHandler(w http.ResponseWriter, r *http.Request) {
 	var req api.RegistrationRequest
-	err := render.DecodeJSON(r.Body, &req)
-	if err != nil {
-		s.Log.Error("auth: decoding request", logger.Err(err))
-		w.WriteHeader(http.StatusBadRequest)
-		render.JSON(w, r, api.ErrorResponse("bad request"))
-		return
-	}
-	if err := validator.New().Struct(req); err != nil {
-		err = err.(validator.ValidationErrors)
-		w.WriteHeader(http.StatusBadRequest)
-		render.JSON(w, r, api.ErrorResponse(err.Error()))
+	if !s.decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -34,10 +26,15 @@ func (s *HTTPServer) RegistrationHandler(w http.ResponseWriter, r *http.Request)
 		LastName: req.LastName,
 	}
 
-	token, err := s.AuthService.Registration(user)
+	token, err := s.AuthService.Registration(r.Context(), user)
+	if errors.Is(err, authclient.ErrorInvalidToken) {
+		// the auth service answers invalid argument for data it does not accept
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeValidation, "registration data rejected by auth service")
+		return
+	}
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		render.JSON(w, r, api.ErrorResponse(err.Error()))
+		s.Log.Error("registration ", logger.Err(err))
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -49,17 +46,7 @@ func (s *HTTPServer) RegistrationHandler(w http.ResponseWriter, r *http.Request)
 
 func (s *HTTPServer) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	var req api.LoginRequest
-	err := render.DecodeJSON(r.Body, &req)
-	if err != nil {
-		s.Log.Error("auth: decoding request", logger.Err(err))
-		w.WriteHeader(http.StatusBadRequest)
-		render.JSON(w, r, api.ErrorResponse("bad request"))
-		return
-	}
-	if err := validator.New().Struct(req); err != nil {
-		err = err.(validator.ValidationErrors)
-		w.WriteHeader(http.StatusBadRequest)
-		render.JSON(w, r, api.ErrorResponse(err.Error()))
+	if !s.decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -68,10 +55,14 @@ func (s *HTTPServer) LoginHandler(w http.ResponseWriter, r *http.Request) {
 		Password: req.Password,
 	}
 
-	token, err := s.AuthService.Login(user)
+	token, err := s.AuthService.Login(r.Context(), user)
+	if errors.Is(err, authclient.ErrorInvalidToken) {
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeInvalidCredentials, "wrong username or password")
+		return
+	}
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		render.JSON(w, r, api.ErrorResponse(err.Error()))
+		s.Log.Error("login ", logger.Err(err))
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -81,17 +72,25 @@ func (s *HTTPServer) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// UpdateUserRole changes the role by username, ChangeUserRoleHandler does the same by id.
 func (s *HTTPServer) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
 	var req api.UpdateUserRoleRequest
-	render.DecodeJSON(r.Body, &req)
-	// authServie
-	if err := s.AuthService.UpdateUserRole(req.Role, req.Username); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		render.JSON(w, r, api.ErrorResponse(err.Error()))
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+
+	user, err := s.HotelService.GetUserByUsername(r.Context(), req.Username)
+	if err == nil {
+		_, err = s.HotelService.ChangeUserRole(r.Context(), user.Id, req.Role)
+	}
+	if err != nil {
+		s.Log.Error("updating user role ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 	render.JSON(w, r, api.Response{
 		Status: "success",
 	})
-}
\ No newline at end of file
+}
@@ -0,0 +1,55 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Bitummit/booking_api/internal/api"
+	"github.com/Bitummit/booking_api/internal/api/problem"
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/Bitummit/booking_api/pkg/logger"
+	"github.com/go-chi/render"
+)
+
+func (s *HTTPServer) AvailabilityHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := api.AvailabilityRequest{
+		City: query.Get("city"),
+		From: query.Get("from"),
+		To: query.Get("to"),
+	}
+	if guests := query.Get("guests"); guests != "" {
+		count, err := strconv.ParseInt(guests, 10, 64)
+		if err != nil {
+			problem.InvalidParameter(w, r, "guests", "is not int")
+			return
+		}
+		req.Guests = count
+	}
+	if err := validate.Struct(req); err != nil {
+		problem.Validation(w, r, err)
+		return
+	}
+
+	// formats are checked by the validator above
+	from, _ := time.Parse(time.DateOnly, req.From)
+	to, _ := time.Parse(time.DateOnly, req.To)
+	filter := models.AvailabilityFilter{
+		City: req.City,
+		From: from,
+		To: to,
+		Guests: req.Guests,
+	}
+	hotels, err := s.HotelService.SearchAvailability(r.Context(), filter)
+	if err != nil {
+		s.Log.Error("searching availability ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.AvailabilityResponse{
+		Hotels: hotels,
+	})
+}
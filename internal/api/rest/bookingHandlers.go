@@ -0,0 +1,104 @@
+package rest
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Bitummit/booking_api/internal/api"
+	"github.com/Bitummit/booking_api/internal/api/problem"
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/Bitummit/booking_api/pkg/logger"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func (s *HTTPServer) CreateBookingHandler(w http.ResponseWriter, r *http.Request) {
+	var req api.CreateBookingRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+
+	// formats are checked by the validator above
+	entryDate, _ := time.Parse(time.DateOnly, req.EntryDate)
+	leaveDate, _ := time.Parse(time.DateOnly, req.LeaveDate)
+	booking := models.Booking{
+		EntryDate: entryDate,
+		LeaveDate: leaveDate,
+		GuestsCount: req.GuestsCount,
+	}
+	created, err := s.HotelService.CreateBooking(r.Context(), booking, req.HotelId, req.CategoryId)
+	if err != nil {
+		s.Log.Error("creating booking ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	s.Log.Info("New booking", slog.Int64("id", created.Id))
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.BookingResponse{
+		Booking: created,
+	})
+}
+
+func (s *HTTPServer) GuestBookingStatusHandler(w http.ResponseWriter, r *http.Request) {
+	bookingID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problem.InvalidParameter(w, r, "id", "is not int")
+		return
+	}
+	status, ok := s.decodeBookingStatus(w, r)
+	if !ok {
+		return
+	}
+
+	booking, err := s.HotelService.GuestChangeBookingStatus(r.Context(), int64(bookingID), status)
+	if err != nil {
+		s.Log.Error("changing booking status ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.BookingResponse{
+		Booking: booking,
+	})
+}
+
+func (s *HTTPServer) ManagerBookingStatusHandler(w http.ResponseWriter, r *http.Request) {
+	hotelID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problem.InvalidParameter(w, r, "id", "is not int")
+		return
+	}
+	bookingID, err := strconv.Atoi(chi.URLParam(r, "bid"))
+	if err != nil {
+		problem.InvalidParameter(w, r, "bid", "is not int")
+		return
+	}
+	status, ok := s.decodeBookingStatus(w, r)
+	if !ok {
+		return
+	}
+
+	booking, err := s.HotelService.ManagerChangeBookingStatus(r.Context(), int64(hotelID), int64(bookingID), status)
+	if err != nil {
+		s.Log.Error("changing booking status ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.BookingResponse{
+		Booking: booking,
+	})
+}
+
+func (s *HTTPServer) decodeBookingStatus(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req api.BookingStatusRequest
+	if !s.decodeRequest(w, r, &req) {
+		return "", false
+	}
+	return req.Status, true
+}
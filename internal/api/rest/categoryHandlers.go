@@ -0,0 +1,126 @@
+package rest
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Bitummit/booking_api/internal/api"
+	"github.com/Bitummit/booking_api/internal/api/problem"
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/Bitummit/booking_api/pkg/logger"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func (s *HTTPServer) ListCategoriesHandler(w http.ResponseWriter, r *http.Request) {
+	hotelID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problem.InvalidParameter(w, r, "id", "is not int")
+		return
+	}
+
+	categories, err := s.HotelService.ListRoomCategories(r.Context(), int64(hotelID))
+	if err != nil {
+		s.Log.Error("listing room categories ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.ListRoomCategoriesResponse{
+		Categories: categories,
+	})
+}
+
+func (s *HTTPServer) CreateCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	hotelID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problem.InvalidParameter(w, r, "id", "is not int")
+		return
+	}
+
+	var req api.RoomCategoryRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+
+	category := models.RoomCategory{
+		Name: req.Name,
+		Price: req.Price,
+		Capacity: req.Capacity,
+		Desc: req.Desc,
+		Size: req.Size,
+		HotelId: int64(hotelID),
+	}
+	id, err := s.HotelService.CreateRoomCategory(r.Context(), category)
+	if err != nil {
+		s.Log.Error("creating room category ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	s.Log.Info("New room category", slog.Int64("id", id))
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.CreationResponse{
+		Id: id,
+	})
+}
+
+func (s *HTTPServer) UpdateCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	hotelID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problem.InvalidParameter(w, r, "id", "is not int")
+		return
+	}
+	categoryID, err := strconv.Atoi(chi.URLParam(r, "cid"))
+	if err != nil {
+		problem.InvalidParameter(w, r, "cid", "is not int")
+		return
+	}
+
+	var req api.RoomCategoryRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+
+	category := models.RoomCategory{
+		Id: int64(categoryID),
+		Name: req.Name,
+		Price: req.Price,
+		Capacity: req.Capacity,
+		Desc: req.Desc,
+		Size: req.Size,
+		HotelId: int64(hotelID),
+	}
+	if err := s.HotelService.UpdateRoomCategory(r.Context(), category); err != nil {
+		s.Log.Error("updating room category ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.Response{Status: "OK"})
+}
+
+func (s *HTTPServer) DeleteCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	hotelID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problem.InvalidParameter(w, r, "id", "is not int")
+		return
+	}
+	categoryID, err := strconv.Atoi(chi.URLParam(r, "cid"))
+	if err != nil {
+		problem.InvalidParameter(w, r, "cid", "is not int")
+		return
+	}
+
+	if err := s.HotelService.DeleteRoomCategory(r.Context(), int64(hotelID), int64(categoryID)); err != nil {
+		s.Log.Error("deleting room category ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.Response{Status: "OK"})
+}
@@ -1,25 +1,23 @@
 package rest
 
 import (
-	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
 
 	"github.com/Bitummit/booking_api/internal/api"
+	"github.com/Bitummit/booking_api/internal/api/problem"
 	"github.com/Bitummit/booking_api/internal/models"
-	"github.com/Bitummit/booking_api/internal/storage/postgresql"
 	"github.com/Bitummit/booking_api/pkg/logger"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
-	"github.com/go-playground/validator/v10"
 )
 
 func (s *HTTPServer) ListCityHandler(w http.ResponseWriter, r *http.Request) {
 	cities, err := s.HotelService.ListCities(r.Context())
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		render.JSON(w, r, api.ErrorResponse(err.Error()))
+		s.Log.Error("listing cities ", logger.Err(err))
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -31,19 +29,7 @@ func (s *HTTPServer) ListCityHandler(w http.ResponseWriter, r *http.Request) {
 
 func (s *HTTPServer) CreateCityHandler(w http.ResponseWriter, r *http.Request) {
 	var req api.CreateCityRequest
-	err := render.DecodeJSON(r.Body, &req)
-	r.Body.Close()
-	if err != nil {
-		s.Log.Error("decoding request: %v", logger.Err(err))
-		w.WriteHeader(http.StatusBadRequest)
-		render.JSON(w, r, api.ErrorResponse("bad request"))
-		return
-	}
-
-	if err := validator.New().Struct(req); err != nil {
-		err = err.(validator.ValidationErrors)
-		w.WriteHeader(http.StatusBadRequest)
-		render.JSON(w, r, api.ErrorResponse(err.Error()))
+	if !s.decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -52,18 +38,8 @@ func (s *HTTPServer) CreateCityHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	id, err := s.HotelService.CreateCity(r.Context(), city)
 	if err != nil {
-		s.Log.Error("%v", logger.Err(err))
-		if errors.Is(err, postgresql.ErrorInsertion){
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, api.ErrorResponse("insertion error"))
-			return
-		} else if errors.Is(err, postgresql.ErrorExists){
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, api.ErrorResponse("tag aready exists"))
-			return
-		}
-		w.WriteHeader(http.StatusInternalServerError)
-		render.JSON(w, r, api.ErrorResponse(err.Error()))
+		s.Log.Error("creating city ", logger.Err(err))
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -78,23 +54,16 @@ func (s *HTTPServer) CreateCityHandler(w http.ResponseWriter, r *http.Request) {
 func (s *HTTPServer) DeleteCityHandler(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		render.JSON(w, r, api.ErrorResponse("id is not int"))
+		problem.InvalidParameter(w, r, "id", "is not int")
 		return
 	}
 
 	err = s.HotelService.DeleteCity(r.Context(), int64(id))
 	if err != nil {
 		s.Log.Error("deleting city ", logger.Err(err))
-		if errors.Is(err, postgresql.ErrorNotExists) {
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, api.ErrorResponse("city not exists"))
-			return
-		}
-		w.WriteHeader(http.StatusInternalServerError)
-		render.JSON(w, r, api.ErrorResponse("internal error"))
+		problem.Error(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
 	render.JSON(w, r, api.Response{Status: "OK"})
-}
\ No newline at end of file
+}
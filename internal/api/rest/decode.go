@@ -0,0 +1,27 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/Bitummit/booking_api/internal/api/problem"
+	"github.com/Bitummit/booking_api/pkg/logger"
+	"github.com/go-chi/render"
+)
+
+// validate caches struct rules, it is safe for concurrent use.
+var validate = problem.NewValidator()
+
+// decodeRequest decodes the json body into req and validates it. On failure the problem is
+// already written and the handler just returns.
+func (s *HTTPServer) decodeRequest(w http.ResponseWriter, r *http.Request, req any) bool {
+	if err := render.DecodeJSON(r.Body, req); err != nil {
+		s.Log.Error("decoding request", logger.Err(err))
+		problem.MalformedBody(w, r)
+		return false
+	}
+	if err := validate.Struct(req); err != nil {
+		problem.Validation(w, r, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,24 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/Bitummit/booking_api/internal/api"
+	authclient "github.com/Bitummit/booking_api/internal/service/authClient"
+	"github.com/go-chi/render"
+)
+
+// HealthHandler answers 200 while the api is serving, an open auth breaker only degrades it.
+func (s *HTTPServer) HealthHandler(w http.ResponseWriter, r *http.Request) {
+	state := s.AuthService.BreakerState()
+	status := "ok"
+	if state != authclient.BreakerClosed {
+		status = "degraded"
+	}
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.HealthResponse{
+		Status: status,
+		AuthBreaker: state,
+	})
+}
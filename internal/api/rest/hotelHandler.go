@@ -2,14 +2,21 @@ package rest
 
 import (
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Bitummit/booking_api/internal/api"
+	"github.com/Bitummit/booking_api/internal/api/problem"
 	"github.com/Bitummit/booking_api/internal/models"
 	"github.com/Bitummit/booking_api/internal/storage/postgresql"
 	"github.com/Bitummit/booking_api/pkg/logger"
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
-	"github.com/go-playground/validator/v10"
 )
 
 func (s *HTTPServer) CreateHotelHandler(w http.ResponseWriter, r *http.Request) {
@@ -18,18 +25,7 @@ func (s *HTTPServer) CreateHotelHandler(w http.ResponseWriter, r *http.Request)
 	// 	Desc string 	`json:"desc,omitempty"`
 	// 	City string 	`json:"city"`
 	// 	Tags []string	`json:"tags"`
-	err :=render.DecodeJSON(r.Body, &req)
-	if err != nil {
-		s.Log.Error("hotel: decoding request", logger.Err(err))
-		w.WriteHeader(http.StatusBadRequest)
-		render.JSON(w, r, api.ErrorResponse("bad request"))
-		return
-	}
-
-	if err := validator.New().Struct(req); err != nil {
-		err = err.(validator.ValidationErrors)
-		w.WriteHeader(http.StatusBadRequest)
-		render.JSON(w, r, api.ErrorResponse(err.Error()))
+	if !s.decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -40,42 +36,22 @@ func (s *HTTPServer) CreateHotelHandler(w http.ResponseWriter, r *http.Request)
 	hotelID, err := s.HotelService.CreateHotel(r.Context(), hotel, req.City, req.Tags)
 	if err != nil {
 		s.Log.Error("hotel:", logger.Err(err))
-		w.WriteHeader(http.StatusBadRequest)
-		if errors.Is(err, postgresql.ErrorTagNotExists) {
-			render.JSON(w, r, api.ErrorResponse("no such tag"))
-			return
-		}
-		if errors.Is(err, postgresql.ErrorCityNotExists) {
-			render.JSON(w, r, api.ErrorResponse("no such city"))
-			return
-		}
-		if errors.Is(err, postgresql.ErrorExists) {
-			render.JSON(w, r, api.ErrorResponse("hotel with this name exists!"))
-			return
-		}
-		if errors.Is(err, postgresql.ErrorNotExists) || errors.Is(err, postgresql.ErrorInsertion){
-			render.JSON(w, r, api.ErrorResponse("bad request"))
-			return
-		}
-		w.WriteHeader(http.StatusInternalServerError)
-		render.JSON(w, r, api.ErrorResponse("internal error"))
+		problem.Error(w, r, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
 	res := api.CreationResponse{
 		Id: hotelID,
 	}
 	w.WriteHeader(http.StatusOK)
 	render.JSON(w, r, res)
-
 }
 
 func (s *HTTPServer) ListOwnHotels(w http.ResponseWriter, r *http.Request) {
 	hotels, err := s.HotelService.ListHotels(r.Context())
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		render.JSON(w, r, api.ErrorResponse(err.Error()))
+		s.Log.Error("listing own hotels ", logger.Err(err))
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -83,4 +59,227 @@ func (s *HTTPServer) ListOwnHotels(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, api.ListHotelsResponse{
 		Hotels: hotels,
 	})
-}
\ No newline at end of file
+}
+func (s *HTTPServer) SearchHotelsHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := parseSearchHotelsRequest(r.URL.Query())
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, err.Error())
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		problem.Validation(w, r, err)
+		return
+	}
+
+	filter := models.HotelFilter{
+		City: req.City,
+		Tags: req.Tags,
+		AllTags: req.TagsMode == "all",
+		Name: req.Name,
+		PriceFrom: req.PriceFrom,
+		PriceTo: req.PriceTo,
+		Capacity: req.Capacity,
+		Sort: req.Sort,
+		Desc: req.Order == "desc",
+		Limit: req.Limit,
+	}
+	hotels, next, err := s.HotelService.SearchHotels(r.Context(), filter, req.Cursor)
+	if err != nil {
+		s.Log.Error("searching hotels ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.SearchHotelsResponse{
+		Hotels: hotels,
+		NextCursor: next,
+	})
+}
+
+func parseSearchHotelsRequest(query url.Values) (api.SearchHotelsRequest, error) {
+	req := api.SearchHotelsRequest{
+		City: query.Get("city"),
+		TagsMode: query.Get("tags_mode"),
+		Name: query.Get("q"),
+		Sort: query.Get("sort"),
+		Order: query.Get("order"),
+		Cursor: query.Get("cursor"),
+	}
+	for _, tags := range query["tags"] {
+		for _, tag := range strings.Split(tags, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				req.Tags = append(req.Tags, tag)
+			}
+		}
+	}
+
+	var err error
+	if v := query.Get("price_from"); v != "" {
+		if req.PriceFrom, err = strconv.ParseFloat(v, 64); err != nil {
+			return req, fmt.Errorf("price_from is not a number")
+		}
+	}
+	if v := query.Get("price_to"); v != "" {
+		if req.PriceTo, err = strconv.ParseFloat(v, 64); err != nil {
+			return req, fmt.Errorf("price_to is not a number")
+		}
+	}
+	if v := query.Get("capacity"); v != "" {
+		if req.Capacity, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return req, fmt.Errorf("capacity is not int")
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		if req.Limit, err = strconv.Atoi(v); err != nil {
+			return req, fmt.Errorf("limit is not int")
+		}
+	}
+
+	return req, nil
+}
+
+func (s *HTTPServer) GetHotelHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problem.InvalidParameter(w, r, "id", "is not int")
+		return
+	}
+
+	query := r.URL.Query()
+	req := api.HotelStayRequest{
+		From: query.Get("from"),
+		To: query.Get("to"),
+	}
+	if guests := query.Get("guests"); guests != "" {
+		if req.Guests, err = strconv.ParseInt(guests, 10, 64); err != nil {
+			problem.InvalidParameter(w, r, "guests", "is not int")
+			return
+		}
+	}
+	if err := validate.Struct(req); err != nil {
+		problem.Validation(w, r, err)
+		return
+	}
+
+	var stay *models.AvailabilityFilter
+	if req.From != "" {
+		// formats are checked by the validator above
+		from, _ := time.Parse(time.DateOnly, req.From)
+		to, _ := time.Parse(time.DateOnly, req.To)
+		stay = &models.AvailabilityFilter{
+			From: from,
+			To: to,
+			Guests: req.Guests,
+		}
+	}
+
+	hotel, availability, err := s.HotelService.GetHotel(r.Context(), int64(id), stay)
+	if err != nil {
+		s.Log.Error("getting hotel ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.HotelResponse{
+		Hotel: hotel,
+		Availability: availability,
+	})
+}
+
+func (s *HTTPServer) UpdateHotelHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problem.InvalidParameter(w, r, "id", "is not int")
+		return
+	}
+
+	var req api.UpdateHotelRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+
+	update := models.HotelUpdate{
+		Name: req.Name,
+		Desc: req.Desc,
+		City: req.City,
+		Tags: req.Tags,
+	}
+	if err := s.HotelService.UpdateHotel(r.Context(), int64(id), update); err != nil {
+		s.Log.Error("updating hotel ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.Response{Status: "OK"})
+}
+
+func (s *HTTPServer) DeleteHotelHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problem.InvalidParameter(w, r, "id", "is not int")
+		return
+	}
+
+	if err := s.HotelService.DeleteHotel(r.Context(), int64(id)); err != nil {
+		s.Log.Error("deleting hotel ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.Response{Status: "OK"})
+}
+
+
+func (s *HTTPServer) TransferHotelHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problem.InvalidParameter(w, r, "id", "is not int")
+		return
+	}
+
+	var req api.TransferHotelRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+
+	transfer, err := s.HotelService.TransferHotel(r.Context(), int64(id), req.ManagerId)
+	if errors.Is(err, postgresql.ErrorUserNotExists) {
+		problem.Write(w, r, http.StatusUnprocessableEntity, problem.CodeUserNotFound, "no such manager")
+		return
+	}
+	if err != nil {
+		s.Log.Error("transferring hotel ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	s.Log.Info("Hotel transferred", slog.Int64("id", transfer.HotelId), slog.Int64("manager_id", transfer.ToManagerId))
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.TransferHotelResponse{
+		Transfer: transfer,
+	})
+}
+
+func (s *HTTPServer) ListHotelTransfersHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problem.InvalidParameter(w, r, "id", "is not int")
+		return
+	}
+
+	transfers, err := s.HotelService.ListHotelTransfers(r.Context(), int64(id))
+	if err != nil {
+		s.Log.Error("listing hotel transfers ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.ListHotelTransfersResponse{
+		Transfers: transfers,
+	})
+}
@@ -0,0 +1,101 @@
+package rest
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Bitummit/booking_api/internal/api"
+	"github.com/Bitummit/booking_api/internal/api/problem"
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/Bitummit/booking_api/pkg/logger"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func (s *HTTPServer) ListRoomsHandler(w http.ResponseWriter, r *http.Request) {
+	hotelID, categoryID, ok := parseCategoryPath(w, r)
+	if !ok {
+		return
+	}
+
+	rooms, err := s.HotelService.ListRooms(r.Context(), hotelID, categoryID)
+	if err != nil {
+		s.Log.Error("listing rooms ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.ListRoomsResponse{
+		Rooms: rooms,
+	})
+}
+
+func (s *HTTPServer) CreateRoomHandler(w http.ResponseWriter, r *http.Request) {
+	hotelID, categoryID, ok := parseCategoryPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req api.CreateRoomRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+
+	room := models.Room{
+		Number: req.Number,
+		CategoryId: categoryID,
+	}
+	id, err := s.HotelService.CreateRoom(r.Context(), hotelID, room)
+	if err != nil {
+		s.Log.Error("creating room ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	s.Log.Info("New room", slog.Int64("id", id))
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.CreationResponse{
+		Id: id,
+	})
+}
+
+func (s *HTTPServer) DeleteRoomHandler(w http.ResponseWriter, r *http.Request) {
+	hotelID, categoryID, ok := parseCategoryPath(w, r)
+	if !ok {
+		return
+	}
+	roomID, err := strconv.Atoi(chi.URLParam(r, "rid"))
+	if err != nil {
+		problem.InvalidParameter(w, r, "rid", "is not int")
+		return
+	}
+
+	retired, err := s.HotelService.DeleteRoom(r.Context(), hotelID, categoryID, int64(roomID))
+	if err != nil {
+		s.Log.Error("deleting room ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.DeleteRoomResponse{
+		Status: "OK",
+		Retired: retired,
+	})
+}
+
+func parseCategoryPath(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	hotelID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problem.InvalidParameter(w, r, "id", "is not int")
+		return 0, 0, false
+	}
+	categoryID, err := strconv.Atoi(chi.URLParam(r, "cid"))
+	if err != nil {
+		problem.InvalidParameter(w, r, "cid", "is not int")
+		return 0, 0, false
+	}
+	return int64(hotelID), int64(categoryID), true
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"sync"
 
+	"github.com/Bitummit/booking_api/internal/api/problem"
 	"github.com/Bitummit/booking_api/internal/middlewares"
 	"github.com/Bitummit/booking_api/internal/models"
 	"github.com/Bitummit/booking_api/internal/service"
@@ -34,38 +35,82 @@ type (
 		DeleteCity(ctx context.Context, id int64) error
 		CreateHotel(ctx context.Context, hotel models.Hotel, cityName string, tags []string) (int64, error)
 		ListHotels(ctx context.Context) ([]*models.Hotel, error)
+		GetHotel(ctx context.Context, id int64, stay *models.AvailabilityFilter) (*models.Hotel, []models.CategoryAvailability, error)
+		UpdateHotel(ctx context.Context, id int64, update models.HotelUpdate) error
+		DeleteHotel(ctx context.Context, id int64) error
+		TransferHotel(ctx context.Context, hotelID, managerID int64) (*models.HotelTransfer, error)
+		ListHotelTransfers(ctx context.Context, hotelID int64) ([]models.HotelTransfer, error)
+		CreateRoomCategory(ctx context.Context, category models.RoomCategory) (int64, error)
+		ListRoomCategories(ctx context.Context, hotelID int64) ([]models.RoomCategory, error)
+		UpdateRoomCategory(ctx context.Context, category models.RoomCategory) error
+		DeleteRoomCategory(ctx context.Context, hotelID, id int64) error
+		CreateRoom(ctx context.Context, hotelID int64, room models.Room) (int64, error)
+		ListRooms(ctx context.Context, hotelID, categoryID int64) ([]models.Room, error)
+		DeleteRoom(ctx context.Context, hotelID, categoryID, id int64) (bool, error)
+		CreateBooking(ctx context.Context, booking models.Booking, hotelID, categoryID int64) (*models.Booking, error)
+		GuestChangeBookingStatus(ctx context.Context, bookingID int64, status string) (*models.Booking, error)
+		ManagerChangeBookingStatus(ctx context.Context, hotelID, bookingID int64, status string) (*models.Booking, error)
+		SearchHotels(ctx context.Context, filter models.HotelFilter, cursor string) ([]*models.Hotel, string, error)
+		SearchAvailability(ctx context.Context, filter models.AvailabilityFilter) ([]models.HotelAvailability, error)
+		CheckHotelManager(ctx context.Context, hotelID int64) error
+		ListUsers(ctx context.Context, filter models.UserFilter) ([]models.User, error)
+		GetUser(ctx context.Context, id int64) (*models.User, error)
+		GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+		IsUserBlocked(ctx context.Context, id int64) (bool, error)
+		ChangeUserRole(ctx context.Context, id int64, role models.Role) (*models.User, error)
+		SetUserBlocked(ctx context.Context, id int64, blocked bool) (*models.User, error)
+		UpdateProfile(ctx context.Context, update models.ProfileUpdate) (*models.User, error)
+		ListAudit(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
 	}
 )
 
 func New(cfg *config.Config, log *slog.Logger, storage service.HotelStorage) (*HTTPServer, error){
-	router := chi.NewRouter()
 	hotelService := service.New(storage)
 
 	auth, err := authclient.New(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("%w", err)
 	}
+	hotelService.Roles = auth
 
-	return &HTTPServer{
+	return NewWithAuth(cfg, log, hotelService, auth), nil
+}
+
+// NewWithAuth builds a server with routes mounted on Router, so it can be served by
+// Start or by an httptest.Server with a fake auth service.
+func NewWithAuth(cfg *config.Config, log *slog.Logger, hotelService HotelService, auth *authclient.Client) *HTTPServer {
+	s := &HTTPServer{
 		Cfg: cfg,
 		Log: log,
 		HotelService: hotelService,
 		AuthService: auth,
-		Router: router,
-	}, nil
+		Router: chi.NewRouter(),
+	}
+	s.mountRoutes()
+	return s
 }
 
-func (s *HTTPServer) Start(ctx context.Context, wg *sync.WaitGroup) error {
+func (s *HTTPServer) mountRoutes() {
 	s.Router.Use(middleware.RequestID)
 	s.Router.Use(middleware.RealIP)
 	s.Router.Use(middleware.Logger)
 	s.Router.Use(middleware.Recoverer)
 	s.Router.Use(middleware.URLFormat)
 	s.Router.Use(middlewares.SetJSONContentType)
-	s.Router.Use(middlewares.GetUser(s.Cfg, s.Log))
+	s.Router.Use(middlewares.GetUser(s.AuthService, s.HotelService, s.Log))
+	s.Router.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		problem.Write(w, r, http.StatusNotFound, problem.CodeNotFound, "no such route")
+	})
+	s.Router.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		problem.Write(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, "method not allowed")
+	})
+
+	managerOnly := middlewares.RequireRole(models.RoleManager, models.RoleAdmin)
+	// routes of one hotel are open to its manager and admins only
+	hotelManager := []func(http.Handler) http.Handler{managerOnly, middlewares.RequireHotelManager(s.HotelService)}
 
 	s.Router.Route("/admin", func(r chi.Router) {
-		r.Use(middlewares.IsAdmin(s.Cfg))
+		r.Use(middlewares.RequireRole(models.RoleAdmin))
 
 		r.Route("/tags", func(r chi.Router) {
 			r.Post("/", s.CreateTagHandler)
@@ -77,13 +122,57 @@ func (s *HTTPServer) Start(ctx context.Context, wg *sync.WaitGroup) error {
 			r.Get("/", s.ListCityHandler)
 			r.Delete("/{id}", s.DeleteCityHandler)
 		})
+		r.Route("/hotels/{id}", func(r chi.Router) {
+			r.Post("/transfer", s.TransferHotelHandler)
+			r.Get("/transfers", s.ListHotelTransfersHandler)
+		})
 		r.Post("/role/update", s.UpdateUserRole)
+		r.Get("/audit", s.ListAuditHandler)
+		r.Route("/users", func(r chi.Router) {
+			r.Get("/", s.ListUsersHandler)
+			r.Get("/{id}", s.GetUserHandler)
+			r.Put("/{id}/role", s.ChangeUserRoleHandler)
+			r.Post("/{id}/block", s.BlockUserHandler)
+			r.Post("/{id}/unblock", s.UnblockUserHandler)
+			r.Get("/{id}/audit", s.ListUserAuditHandler)
+		})
+	})
+	s.Router.With(managerOnly).Post("/hotels", s.CreateHotelHandler) // manager role or admin
+	s.Router.Get("/hotels", s.SearchHotelsHandler) // all
+	s.Router.With(managerOnly).Get("/hotels/own", s.ListOwnHotels) // manager role
+	s.Router.Get("/hotels/{id}", s.GetHotelHandler) // all
+	s.Router.With(hotelManager...).Patch("/hotels/{id}", s.UpdateHotelHandler) // hotel manager or admin
+	s.Router.With(hotelManager...).Delete("/hotels/{id}", s.DeleteHotelHandler) // hotel manager or admin
+	s.Router.Route("/hotels/{id}/categories", func(r chi.Router) {
+		r.Get("/", s.ListCategoriesHandler) // all
+		r.With(hotelManager...).Post("/", s.CreateCategoryHandler) // hotel manager or admin
+		r.With(hotelManager...).Put("/{cid}", s.UpdateCategoryHandler) // hotel manager or admin
+		r.With(hotelManager...).Delete("/{cid}", s.DeleteCategoryHandler) // hotel manager or admin
+		r.Route("/{cid}/rooms", func(r chi.Router) {
+			r.Use(hotelManager...)
+			r.Get("/", s.ListRoomsHandler) // hotel manager or admin
+			r.Post("/", s.CreateRoomHandler) // hotel manager or admin
+			r.Delete("/{rid}", s.DeleteRoomHandler) // hotel manager or admin
+		})
 	})
-	s.Router.Post("/hotels", s.CreateHotelHandler) // manager role or admin
-	s.Router.Get("/hotels", s.ListOwnHotels) // manager role
+	s.Router.Get("/availability", s.AvailabilityHandler) // all
+	s.Router.With(hotelManager...).Post("/hotels/{id}/bookings/{bid}/status", s.ManagerBookingStatusHandler) // hotel manager or admin
+	s.Router.With(middlewares.RequireAuth).Post("/bookings", s.CreateBookingHandler) // authenticated user
+	s.Router.With(middlewares.RequireAuth).Post("/bookings/{id}/status", s.GuestBookingStatusHandler) // booking owner
+	s.Router.Group(func(r chi.Router) {
+		r.Use(middlewares.RequireAuth)
+		r.Get("/me", s.MeHandler) // authenticated user
+		r.Patch("/me", s.UpdateMeHandler) // authenticated user
+		r.Post("/token/refresh", s.RefreshTokenHandler) // authenticated user
+		r.Post("/logout", s.LogoutHandler) // authenticated user
+		r.Post("/password/change", s.ChangePasswordHandler) // authenticated user
+	})
+	s.Router.Get("/health", s.HealthHandler) // all
 	s.Router.Post("/signup", s.RegistrationHandler) // all
 	s.Router.Post("/login", s.LoginHandler) // all
+}
 
+func (s *HTTPServer) Start(ctx context.Context, wg *sync.WaitGroup) error {
 	errCh := make(chan error, 1)
 	httpServer := &http.Server{
 		Addr: s.Cfg.Address,
@@ -114,10 +203,10 @@ func (s *HTTPServer) Start(ctx context.Context, wg *sync.WaitGroup) error {
 
 // User:
 // 	List hotels -> done
-// 	Get hotel -> show list room_categories
-// 	Create booking (auth)
+// 	Get hotel -> show list room_categories -> done
+// 	Create booking (auth) -> done
 // 	List booking
-// 	Hotels filter and pagination
+// 	Hotels filter and pagination -> done
 
 // Admin (DONE):
 // 	Update user role (give role manager) (auth_service) -> done
@@ -127,10 +216,10 @@ func (s *HTTPServer) Start(ctx context.Context, wg *sync.WaitGroup) error {
 // Manager:
 //	List own hotels -> Done
 //	Create hotel -> done
-// 	Get hotel - NEXT
-//	Create, update, delete categories
-//	Create, delete room
-// 	Update hotel
+// 	Get hotel -> done
+//	Create, update, delete categories -> done
+//	Create, delete room -> done
+// 	Update hotel -> done
 
 // Mailmicroservice: (Kafka)*
 // Send email with booking info
@@ -0,0 +1,399 @@
+package rest_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Bitummit/booking_api/internal/api"
+	"github.com/Bitummit/booking_api/internal/api/problem"
+	"github.com/Bitummit/booking_api/internal/api/rest"
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/Bitummit/booking_api/internal/service"
+	authclient "github.com/Bitummit/booking_api/internal/service/authClient"
+	"github.com/Bitummit/booking_api/internal/service/authClient/fakeauth"
+	"github.com/Bitummit/booking_api/internal/storage/memory"
+	"github.com/Bitummit/booking_api/pkg/config"
+	"google.golang.org/grpc/codes"
+)
+
+// env is a REST server backed by the memory storage and the fake auth service.
+type env struct {
+	url string
+	auth *fakeauth.Server
+	client *authclient.Client
+	storage *memory.Storage
+}
+
+func newEnv(t *testing.T) *env {
+	t.Helper()
+	fake := fakeauth.Start()
+	t.Cleanup(fake.Close)
+	cfg := &config.Config{}
+	cfg.AuthTimeout = time.Second
+	// users are cached like in production, role changes have to drop them
+	cfg.UserCacheTTL = time.Minute
+	cfg.UserCacheSize = 100
+	client, err := fake.Client(cfg)
+	if err != nil {
+		t.Fatalf("creating auth client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	storage := memory.New()
+	hotels := service.New(storage)
+	hotels.Roles = client
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := httptest.NewServer(rest.NewWithAuth(cfg, log, hotels, client).Router)
+	t.Cleanup(server.Close)
+
+	return &env{url: server.URL, auth: fake, client: client, storage: storage}
+}
+
+// addUser registers the user with the auth service and in the user table, which the auth
+// service shares with the api in production, and returns a token for it.
+func (e *env) addUser(t *testing.T, username string, role models.Role) string {
+	t.Helper()
+	user := models.User{Username: username, FirstName: username, Email: username + "@example.com", Role: role}
+	token := e.auth.AddUser(user, "secret", role)
+	authUser, _ := e.auth.User(username)
+	if id := e.storage.AddUser(user); id != authUser.Id {
+		t.Fatalf("user %s: stored with id %d, the auth service gave %d", username, id, authUser.Id)
+	}
+	return token
+}
+
+type response struct {
+	status int
+	contentType string
+	body []byte
+}
+
+func (e *env) do(t *testing.T, method, path, token string, body any) response {
+	t.Helper()
+	var reqBody io.Reader
+	switch body := body.(type) {
+	case nil:
+	case string:
+		reqBody = bytes.NewBufferString(body)
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("encoding request: %v", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, e.url+path, reqBody)
+	if err != nil {
+		t.Fatalf("creating request: %v", err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading response: %v", err)
+	}
+	return response{status: resp.StatusCode, contentType: resp.Header.Get("Content-Type"), body: data}
+}
+
+// decode checks the status and decodes a successful json body into v.
+func (r response) decode(t *testing.T, status int, v any) {
+	t.Helper()
+	if r.status != status {
+		t.Fatalf("status = %d, want %d: %s", r.status, status, r.body)
+	}
+	if r.contentType != "application/json" {
+		t.Fatalf("content type = %q, want application/json", r.contentType)
+	}
+	if err := json.Unmarshal(r.body, v); err != nil {
+		t.Fatalf("decoding %s: %v", r.body, err)
+	}
+}
+
+// problem checks the status and the stable code of a problem response.
+func (r response) problem(t *testing.T, status int, code string) {
+	t.Helper()
+	if r.status != status {
+		t.Fatalf("status = %d, want %d: %s", r.status, status, r.body)
+	}
+	if r.contentType != problem.ContentType {
+		t.Fatalf("content type = %q, want %s", r.contentType, problem.ContentType)
+	}
+	var p problem.Problem
+	if err := json.Unmarshal(r.body, &p); err != nil {
+		t.Fatalf("decoding problem %s: %v", r.body, err)
+	}
+	if p.Status != status || p.Code != code {
+		t.Fatalf("problem = %d %s, want %d %s", p.Status, p.Code, status, code)
+	}
+}
+
+func TestSignup(t *testing.T) {
+	e := newEnv(t)
+	signup := api.RegistrationRequest{Username: "alice", Password: "secret", Email: "alice@example.com", FirstName: "Alice"}
+
+	var registered api.RegistrationResponse
+	e.do(t, http.MethodPost, "/signup", "", signup).decode(t, http.StatusOK, &registered)
+	if registered.Token == "" {
+		t.Fatal("signup returned no token")
+	}
+	// the token is accepted right away
+	var me api.UserResponse
+	e.do(t, http.MethodGet, "/me", registered.Token, nil).decode(t, http.StatusOK, &me)
+	if me.Username != "alice" || me.FirstName != "Alice" || me.Role != models.RoleClient {
+		t.Fatalf("me = %+v", me)
+	}
+
+	e.do(t, http.MethodPost, "/signup", "", signup).problem(t, http.StatusConflict, problem.CodeAlreadyExists)
+	e.do(t, http.MethodPost, "/signup", "", api.RegistrationRequest{Username: "bob"}).problem(t, http.StatusBadRequest, problem.CodeValidation)
+	e.do(t, http.MethodPost, "/signup", "", "{").problem(t, http.StatusBadRequest, problem.CodeMalformedBody)
+}
+
+func TestLogin(t *testing.T) {
+	e := newEnv(t)
+	e.addUser(t, "alice", models.RoleClient)
+
+	var login api.LoginResponse
+	e.do(t, http.MethodPost, "/login", "", api.LoginRequest{Username: "alice", Password: "secret"}).decode(t, http.StatusOK, &login)
+	var me api.UserResponse
+	e.do(t, http.MethodGet, "/me", login.Token, nil).decode(t, http.StatusOK, &me)
+	if me.Username != "alice" {
+		t.Fatalf("me = %+v", me)
+	}
+
+	e.do(t, http.MethodPost, "/login", "", api.LoginRequest{Username: "alice", Password: "wrong"}).
+		problem(t, http.StatusUnauthorized, problem.CodeInvalidCredentials)
+	e.do(t, http.MethodPost, "/login", "", api.LoginRequest{Username: "nobody", Password: "secret"}).
+		problem(t, http.StatusUnauthorized, problem.CodeInvalidCredentials)
+
+	e.auth.FailWith(codes.Unavailable)
+	defer e.auth.FailWith(codes.OK)
+	e.do(t, http.MethodPost, "/login", "", api.LoginRequest{Username: "alice", Password: "secret"}).
+		problem(t, http.StatusServiceUnavailable, problem.CodeAuthUnavailable)
+}
+
+func TestAdminRequiresAdmin(t *testing.T) {
+	e := newEnv(t)
+	client := e.addUser(t, "client", models.RoleClient)
+	manager := e.addUser(t, "manager", models.RoleManager)
+
+	for _, path := range []string{"/admin/tags", "/admin/cities", "/admin/users", "/admin/audit"} {
+		e.do(t, http.MethodGet, path, "", nil).problem(t, http.StatusUnauthorized, problem.CodeUnauthorized)
+		e.do(t, http.MethodGet, path, client, nil).problem(t, http.StatusForbidden, problem.CodeForbidden)
+		e.do(t, http.MethodGet, path, manager, nil).problem(t, http.StatusForbidden, problem.CodeForbidden)
+	}
+	e.do(t, http.MethodPost, "/admin/tags", manager, api.CreateTagRequest{Name: "wifi"}).problem(t, http.StatusForbidden, problem.CodeForbidden)
+	e.do(t, http.MethodPost, "/admin/role/update", manager, api.UpdateUserRoleRequest{Username: "client", Role: models.RoleAdmin}).
+		problem(t, http.StatusForbidden, problem.CodeForbidden)
+}
+
+func TestAdminTagsAndCities(t *testing.T) {
+	e := newEnv(t)
+	admin := e.addUser(t, "admin", models.RoleAdmin)
+
+	var created api.CreationResponse
+	e.do(t, http.MethodPost, "/admin/tags", admin, api.CreateTagRequest{Name: "wifi"}).decode(t, http.StatusOK, &created)
+	if created.Id == 0 {
+		t.Fatal("creating tag returned no id")
+	}
+	var tags api.ListTagResponse
+	e.do(t, http.MethodGet, "/admin/tags", admin, nil).decode(t, http.StatusOK, &tags)
+	if len(tags.Tags) != 1 || tags.Tags[0].Name != "wifi" || tags.Tags[0].Id != created.Id {
+		t.Fatalf("tags = %+v", tags.Tags)
+	}
+	e.do(t, http.MethodPost, "/admin/tags", admin, api.CreateTagRequest{Name: "wifi"}).problem(t, http.StatusConflict, problem.CodeAlreadyExists)
+	e.do(t, http.MethodPost, "/admin/tags", admin, api.CreateTagRequest{}).problem(t, http.StatusBadRequest, problem.CodeValidation)
+	e.do(t, http.MethodDelete, "/admin/tags/wifi", admin, nil).problem(t, http.StatusBadRequest, problem.CodeInvalidParameter)
+
+	var deleted api.Response
+	e.do(t, http.MethodDelete, "/admin/tags/1", admin, nil).decode(t, http.StatusOK, &deleted)
+	if deleted.Status != "OK" {
+		t.Fatalf("deleting tag: status %q", deleted.Status)
+	}
+	e.do(t, http.MethodDelete, "/admin/tags/1", admin, nil).problem(t, http.StatusNotFound, problem.CodeNotFound)
+
+	e.do(t, http.MethodPost, "/admin/cities", admin, api.CreateCityRequest{Name: "Almaty"}).decode(t, http.StatusOK, &created)
+	var cities api.ListCityResponse
+	e.do(t, http.MethodGet, "/admin/cities", admin, nil).decode(t, http.StatusOK, &cities)
+	if len(cities.Cities) != 1 || cities.Cities[0].Name != "Almaty" || cities.Cities[0].Id != created.Id {
+		t.Fatalf("cities = %+v", cities.Cities)
+	}
+	e.do(t, http.MethodPost, "/admin/cities", admin, api.CreateCityRequest{Name: "Almaty"}).problem(t, http.StatusConflict, problem.CodeAlreadyExists)
+	e.do(t, http.MethodDelete, "/admin/cities/1", admin, nil).decode(t, http.StatusOK, &deleted)
+}
+
+func TestAdminUsers(t *testing.T) {
+	e := newEnv(t)
+	admin := e.addUser(t, "admin", models.RoleAdmin)
+	e.addUser(t, "bob", models.RoleClient)
+
+	var users api.ListUsersResponse
+	e.do(t, http.MethodGet, "/admin/users", admin, nil).decode(t, http.StatusOK, &users)
+	if len(users.Users) != 2 {
+		t.Fatalf("users = %+v", users.Users)
+	}
+	var user api.UserResponse
+	e.do(t, http.MethodGet, "/admin/users/2", admin, nil).decode(t, http.StatusOK, &user)
+	if user.Username != "bob" || user.Email != "bob@example.com" || user.Blocked {
+		t.Fatalf("user = %+v", user)
+	}
+	e.do(t, http.MethodGet, "/admin/users/99", admin, nil).problem(t, http.StatusNotFound, problem.CodeUserNotFound)
+
+	e.do(t, http.MethodPost, "/admin/users/2/block", admin, nil).decode(t, http.StatusOK, &user)
+	if !user.Blocked {
+		t.Fatalf("blocked user = %+v", user)
+	}
+}
+
+func TestAdminUpdateUserRole(t *testing.T) {
+	e := newEnv(t)
+	admin := e.addUser(t, "admin", models.RoleAdmin)
+	bob := e.addUser(t, "bob", models.RoleClient)
+
+	e.do(t, http.MethodGet, "/hotels/own", bob, nil).problem(t, http.StatusForbidden, problem.CodeForbidden)
+
+	var resp api.Response
+	e.do(t, http.MethodPost, "/admin/role/update", admin, api.UpdateUserRoleRequest{Username: "bob", Role: models.RoleManager}).
+		decode(t, http.StatusOK, &resp)
+	if resp.Status != "success" {
+		t.Fatalf("status = %q", resp.Status)
+	}
+	if user, _ := e.auth.User("bob"); user.Role != models.RoleManager {
+		t.Fatalf("auth service role = %s, want manager", user.Role)
+	}
+	// the cached user is dropped, the next request sees the new role
+	var hotels api.ListHotelsResponse
+	e.do(t, http.MethodGet, "/hotels/own", bob, nil).decode(t, http.StatusOK, &hotels)
+
+	var audit api.AuditResponse
+	e.do(t, http.MethodGet, "/admin/users/2/audit", admin, nil).decode(t, http.StatusOK, &audit)
+	if len(audit.Entries) != 1 || audit.Entries[0].Action != models.AuditChangeRole {
+		t.Fatalf("audit = %+v", audit.Entries)
+	}
+
+	e.do(t, http.MethodPost, "/admin/role/update", admin, api.UpdateUserRoleRequest{Username: "nobody", Role: models.RoleManager}).
+		problem(t, http.StatusNotFound, problem.CodeUserNotFound)
+	e.do(t, http.MethodPost, "/admin/role/update", admin, api.UpdateUserRoleRequest{Username: "bob", Role: "owner"}).
+		problem(t, http.StatusBadRequest, problem.CodeValidation)
+	e.do(t, http.MethodPost, "/admin/role/update", admin, api.UpdateUserRoleRequest{Username: "admin", Role: models.RoleClient}).
+		problem(t, http.StatusForbidden, problem.CodeForbidden)
+
+	e.auth.FailWith(codes.Unavailable)
+	defer e.auth.FailWith(codes.OK)
+	// admin is cached, only the role update reaches the failing auth service
+	e.do(t, http.MethodPost, "/admin/role/update", admin, api.UpdateUserRoleRequest{Username: "bob", Role: models.RoleAdmin}).
+		problem(t, http.StatusServiceUnavailable, problem.CodeAuthUnavailable)
+}
+
+func TestUpdateMe(t *testing.T) {
+	e := newEnv(t)
+	alice := e.addUser(t, "alice", models.RoleClient)
+
+	e.do(t, http.MethodPatch, "/me", "", map[string]string{"first_name": "Al"}).problem(t, http.StatusUnauthorized, problem.CodeUnauthorized)
+
+	var me api.UserResponse
+	e.do(t, http.MethodPatch, "/me", alice, map[string]string{"first_name": "Al", "birthday": "1990-05-17"}).decode(t, http.StatusOK, &me)
+	if me.FirstName != "Al" || me.Birthday != "1990-05-17" || me.Email != "alice@example.com" {
+		t.Fatalf("me = %+v", me)
+	}
+	e.do(t, http.MethodPatch, "/me", alice, map[string]string{"email": "not an email"}).problem(t, http.StatusBadRequest, problem.CodeValidation)
+}
+
+func TestAccountRoutesNotSupported(t *testing.T) {
+	e := newEnv(t)
+	alice := e.addUser(t, "alice", models.RoleClient)
+
+	for _, tt := range []struct {
+		path string
+		body any
+	}{
+		{"/token/refresh", nil},
+		{"/logout", nil},
+		{"/password/change", api.ChangePasswordRequest{OldPassword: "secret", NewPassword: "new-secret"}},
+	} {
+		e.do(t, http.MethodPost, tt.path, "", tt.body).problem(t, http.StatusUnauthorized, problem.CodeUnauthorized)
+		e.do(t, http.MethodPost, tt.path, alice, tt.body).problem(t, http.StatusNotImplemented, problem.CodeNotSupported)
+	}
+}
+
+func TestAccountRoutes(t *testing.T) {
+	e := newEnv(t)
+	e.client.SetAccounts(e.auth.Accounts())
+	alice := e.addUser(t, "alice", models.RoleClient)
+
+	var refreshed api.LoginResponse
+	e.do(t, http.MethodPost, "/token/refresh", alice, nil).decode(t, http.StatusOK, &refreshed)
+	if refreshed.Token == "" || refreshed.Token == alice {
+		t.Fatalf("refreshed token = %q", refreshed.Token)
+	}
+	// the old token is not served from the cache anymore
+	e.do(t, http.MethodGet, "/me", alice, nil).problem(t, http.StatusUnauthorized, problem.CodeUnauthorized)
+	token := refreshed.Token
+
+	e.do(t, http.MethodPost, "/password/change", token, api.ChangePasswordRequest{OldPassword: "wrong", NewPassword: "new-secret"}).
+		problem(t, http.StatusBadRequest, problem.CodeWrongPassword)
+	e.do(t, http.MethodPost, "/password/change", token, api.ChangePasswordRequest{OldPassword: "secret", NewPassword: "short"}).
+		problem(t, http.StatusBadRequest, problem.CodeValidation)
+	var resp api.Response
+	e.do(t, http.MethodPost, "/password/change", token, api.ChangePasswordRequest{OldPassword: "secret", NewPassword: "new-secret"}).
+		decode(t, http.StatusOK, &resp)
+	e.do(t, http.MethodPost, "/login", "", api.LoginRequest{Username: "alice", Password: "new-secret"}).decode(t, http.StatusOK, &refreshed)
+
+	e.do(t, http.MethodPost, "/logout", token, nil).decode(t, http.StatusOK, &resp)
+	if resp.Status != "OK" {
+		t.Fatalf("logout status = %q", resp.Status)
+	}
+	e.do(t, http.MethodGet, "/me", token, nil).problem(t, http.StatusUnauthorized, problem.CodeUnauthorized)
+}
+
+func TestDeletedHotelIsNotListed(t *testing.T) {
+	e := newEnv(t)
+	manager := e.addUser(t, "manager", models.RoleManager)
+	ctx := context.Background()
+	if _, err := e.storage.CreateCity(ctx, models.City{Name: "Almaty"}); err != nil {
+		t.Fatalf("creating city: %v", err)
+	}
+	hotelID, err := e.storage.CreateHotel(ctx, models.Hotel{Name: "Lake", ManagerId: 1}, "Almaty", nil)
+	if err != nil {
+		t.Fatalf("creating hotel: %v", err)
+	}
+	if _, err := e.storage.CreateRoomCategory(ctx, models.RoomCategory{HotelId: hotelID, Name: "Standard", Price: 100, Capacity: 2, Size: 20}); err != nil {
+		t.Fatalf("creating category: %v", err)
+	}
+	path := fmt.Sprintf("/hotels/%d", hotelID)
+
+	var categories api.ListRoomCategoriesResponse
+	e.do(t, http.MethodGet, path+"/categories", "", nil).decode(t, http.StatusOK, &categories)
+	if len(categories.Categories) != 1 {
+		t.Fatalf("categories = %+v", categories.Categories)
+	}
+
+	var resp api.Response
+	e.do(t, http.MethodDelete, path, manager, nil).decode(t, http.StatusOK, &resp)
+
+	var hotels api.ListHotelsResponse
+	e.do(t, http.MethodGet, "/hotels/own", manager, nil).decode(t, http.StatusOK, &hotels)
+	if len(hotels.Hotels) != 0 {
+		t.Fatalf("own hotels after delete = %+v", hotels.Hotels)
+	}
+	var search api.SearchHotelsResponse
+	e.do(t, http.MethodGet, "/hotels", "", nil).decode(t, http.StatusOK, &search)
+	if len(search.Hotels) != 0 {
+		t.Fatalf("hotels after delete = %+v", search.Hotels)
+	}
+	e.do(t, http.MethodGet, path, "", nil).problem(t, http.StatusNotFound, problem.CodeNotFound)
+	e.do(t, http.MethodGet, path+"/categories", "", nil).problem(t, http.StatusNotFound, problem.CodeNotFound)
+}
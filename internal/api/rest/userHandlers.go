@@ -0,0 +1,156 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Bitummit/booking_api/internal/api"
+	"github.com/Bitummit/booking_api/internal/api/problem"
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/Bitummit/booking_api/pkg/logger"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func (s *HTTPServer) ListUsersHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.UserFilter{
+		Query: query.Get("q"),
+		Role: models.Role(query.Get("role")),
+	}
+	if filter.Role != "" && !filter.Role.Valid() {
+		problem.InvalidParameter(w, r, "role", "unknown role")
+		return
+	}
+	if value := query.Get("blocked"); value != "" {
+		blocked, err := strconv.ParseBool(value)
+		if err != nil {
+			problem.InvalidParameter(w, r, "blocked", "is not bool")
+			return
+		}
+		filter.Blocked = &blocked
+	}
+	for name, dst := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			problem.InvalidParameter(w, r, name, "is not a non-negative int")
+			return
+		}
+		*dst = n
+	}
+
+	users, err := s.HotelService.ListUsers(r.Context(), filter)
+	if err != nil {
+		s.Log.Error("listing users ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	resp := api.ListUsersResponse{
+		Users: make([]api.UserResponse, 0, len(users)),
+	}
+	for _, user := range users {
+		resp.Users = append(resp.Users, api.NewUserResponse(&user))
+	}
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, resp)
+}
+
+func (s *HTTPServer) GetUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := s.HotelService.GetUser(r.Context(), id)
+	if err != nil {
+		s.Log.Error("getting user ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.NewUserResponse(user))
+}
+
+func (s *HTTPServer) ChangeUserRoleHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+	var req api.ChangeUserRoleRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+
+	user, err := s.HotelService.ChangeUserRole(r.Context(), id, req.Role)
+	if err != nil {
+		s.Log.Error("changing user role ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.NewUserResponse(user))
+}
+
+func (s *HTTPServer) BlockUserHandler(w http.ResponseWriter, r *http.Request) {
+	s.setUserBlocked(w, r, true)
+}
+
+func (s *HTTPServer) UnblockUserHandler(w http.ResponseWriter, r *http.Request) {
+	s.setUserBlocked(w, r, false)
+}
+
+func (s *HTTPServer) setUserBlocked(w http.ResponseWriter, r *http.Request, blocked bool) {
+	id, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := s.HotelService.SetUserBlocked(r.Context(), id, blocked)
+	if err != nil {
+		s.Log.Error("blocking user ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.NewUserResponse(user))
+}
+
+func (s *HTTPServer) ListUserAuditHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	filter := models.AuditFilter{
+		EntityType: models.EntityUser,
+		EntityId: id,
+	}
+	entries, err := s.HotelService.ListAudit(r.Context(), filter)
+	if err != nil {
+		s.Log.Error("listing user audit ", logger.Err(err))
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, api.AuditResponse{
+		Entries: entries,
+	})
+}
+
+func parseUserID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		problem.InvalidParameter(w, r, "id", "is not int")
+		return 0, false
+	}
+	return int64(id), true
+}
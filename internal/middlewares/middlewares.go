@@ -2,13 +2,18 @@ package middlewares
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 
-	"github.com/Bitummit/booking_api/internal/api"
+	"github.com/Bitummit/booking_api/internal/api/problem"
+	"github.com/Bitummit/booking_api/internal/models"
 	authclient "github.com/Bitummit/booking_api/internal/service/authClient"
-	"github.com/Bitummit/booking_api/pkg/config"
-	"github.com/go-chi/render"
+	"github.com/Bitummit/booking_api/pkg/logger"
+	"github.com/go-chi/chi/v5"
 )
 
 func SetJSONContentType(next http.Handler) http.Handler {
@@ -18,48 +23,108 @@ func SetJSONContentType(next http.Handler) http.Handler {
 	})
 }
 
-func IsAdmin(cfg *config.Config) func(http.Handler) http.Handler {
+// RequireRole lets the request through only for an authenticated user with one of the roles.
+func RequireRole(roles ...models.Role) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler{
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			token := r.Header.Get("Authorization")
-			if token == "" {
-				w.WriteHeader(http.StatusUnauthorized)
-				render.JSON(w, r, api.ErrorResponse("unauthorized"))
+			user, ok := models.UserFromContext(r.Context())
+			if !ok {
+				problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "authentication required")
+				return
+			}
+			if !slices.Contains(roles, user.Role) {
+				problem.Write(w, r, http.StatusForbidden, problem.CodeForbidden, "no enough permission")
 				return
 			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// HotelManagerChecker tells whether the request user may manage the hotel.
+type HotelManagerChecker interface {
+	CheckHotelManager(ctx context.Context, hotelID int64) error
+}
 
-			authClient, err := authclient.New(cfg)
+// RequireHotelManager lets the request through only for admins and the manager of the hotel
+// in the {id} url param. It goes after RequireRole, which answers anonymous requests.
+func RequireHotelManager(hotels HotelManagerChecker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hotelID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, api.ErrorResponse("internal grpc error"))
+				problem.InvalidParameter(w, r, "id", "is not int")
 				return
 			}
-			if err = authClient.CheckIsADmin(token); err != nil {
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, api.ErrorResponse("no enough permission"))
+			if err := hotels.CheckHotelManager(r.Context(), hotelID); err != nil {
+				problem.Error(w, r, err)
 				return
-			} else {
-				next.ServeHTTP(w, r)
 			}
+			next.ServeHTTP(w, r)
 		})
 	}
 }
 
-func GetUser(cfg *config.Config, log *slog.Logger) func(http.Handler) http.Handler {
+// BearerToken returns the request token, the "Bearer " prefix is optional.
+func BearerToken(r *http.Request) string {
+	token := strings.TrimSpace(r.Header.Get("Authorization"))
+	return strings.TrimSpace(strings.TrimPrefix(token, "Bearer "))
+}
+
+// RequireAuth rejects anonymous requests, routes behind it can rely on a user in the context.
+func RequireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := models.UserFromContext(r.Context()); !ok {
+			problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "authentication required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// BlockChecker tells whether an admin blocked the user.
+type BlockChecker interface {
+	IsUserBlocked(ctx context.Context, id int64) (bool, error)
+}
+
+// GetUser puts the user of the request token into the context. Requests without a token or
+// with an invalid or expired one pass as anonymous, routes that need a user are guarded by
+// RequireAuth or RequireRole. A token the auth service forbids and an unreachable auth
+// service fail the request right away. Blocked users are rejected even on public routes.
+func GetUser(authClient *authclient.Client, blocks BlockChecker, log *slog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler{
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			token := r.Header.Get("Authorization")
+			token := BearerToken(r)
+			if token == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
 
-			authClient, err := authclient.New(cfg)
+			user, err := authClient.GetUser(r.Context(), token)
+			if errors.Is(err, authclient.ErrorUnavailable) {
+				log.Error("getting user", logger.Err(err))
+				problem.Error(w, r, err)
+				return
+			}
+			if errors.Is(err, authclient.ErrorPermissionDenied) {
+				log.Info("request with forbidden token", logger.Err(err))
+				problem.Error(w, r, err)
+				return
+			}
 			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, api.ErrorResponse("internal grpc error"))
+				log.Info("anonymous request with rejected token", logger.Err(err))
+				next.ServeHTTP(w, r)
 				return
 			}
-			user, err := authClient.GetUser(token)
+
+			blocked, err := blocks.IsUserBlocked(r.Context(), user.Id)
 			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, api.ErrorResponse("internal grpc error"))
+				log.Error("checking blocked user", logger.Err(err))
+				problem.Error(w, r, err)
+				return
+			}
+			if blocked {
+				problem.Write(w, r, http.StatusForbidden, problem.CodeUserBlocked, "user is blocked")
 				return
 			}
 
@@ -67,8 +132,8 @@ func GetUser(cfg *config.Config, log *slog.Logger) func(http.Handler) http.Handl
 				Key: "user",
 				Value: slog.StringValue(user.Username),
 			})
-			r = r.WithContext(context.WithValue(r.Context(), "user", user))
+			r = r.WithContext(models.ContextWithUser(r.Context(), user))
 			next.ServeHTTP(w, r)
 		})
 	}
-}
\ No newline at end of file
+}
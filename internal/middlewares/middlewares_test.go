@@ -0,0 +1,89 @@
+package middlewares_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Bitummit/booking_api/internal/middlewares"
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/Bitummit/booking_api/internal/service/authClient/fakeauth"
+	"github.com/Bitummit/booking_api/pkg/config"
+	"google.golang.org/grpc/codes"
+)
+
+type blocks map[int64]bool
+
+func (b blocks) IsUserBlocked(_ context.Context, id int64) (bool, error) {
+	return b[id], nil
+}
+
+func TestGetUser(t *testing.T) {
+	fake := fakeauth.Start()
+	defer fake.Close()
+	cfg := &config.Config{}
+	cfg.AuthTimeout = time.Second
+	client, err := fake.Client(cfg)
+	if err != nil {
+		t.Fatalf("creating auth client: %v", err)
+	}
+	defer client.Close()
+
+	alice := fake.AddUser(models.User{Username: "alice"}, "secret", models.RoleManager)
+	mallory := fake.AddUser(models.User{Username: "mallory"}, "secret", "")
+	mw := middlewares.GetUser(client, blocks{2: true}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	// public answers with the user name, protected also needs an authenticated user
+	public := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := models.UserFromContext(r.Context())
+		if !ok {
+			io.WriteString(w, "anonymous")
+			return
+		}
+		io.WriteString(w, user.Username)
+	}))
+	protected := mw(middlewares.RequireAuth(public))
+
+	for _, tt := range []struct {
+		name string
+		handler http.Handler
+		token string
+		failure codes.Code
+		status int
+		body string
+	}{
+		{name: "no token", handler: public, status: http.StatusOK, body: "anonymous"},
+		{name: "valid token", handler: public, token: alice, status: http.StatusOK, body: "alice"},
+		{name: "bearer prefix", handler: public, token: "Bearer " + alice, status: http.StatusOK, body: "alice"},
+		{name: "invalid token", handler: public, token: "expired", status: http.StatusOK, body: "anonymous"},
+		{name: "invalid token on protected route", handler: protected, token: "expired", status: http.StatusUnauthorized},
+		{name: "valid token on protected route", handler: protected, token: alice, status: http.StatusOK, body: "alice"},
+		{name: "blocked user", handler: public, token: mallory, status: http.StatusForbidden},
+		{name: "forbidden token", handler: public, token: alice, failure: codes.PermissionDenied, status: http.StatusForbidden},
+		{name: "forbidden token on protected route", handler: protected, token: alice, failure: codes.PermissionDenied, status: http.StatusForbidden},
+		{name: "auth service down", handler: public, token: alice, failure: codes.Unavailable, status: http.StatusServiceUnavailable},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fake.FailWith(tt.failure)
+			defer fake.FailWith(codes.OK)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", tt.token)
+			}
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.body != "" && rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body, tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package models
+
+import "context"
+
+type contextKey string
+
+const userContextKey contextKey = "user"
+
+// ContextWithUser stores the authenticated user for the rest of the request.
+func ContextWithUser(ctx context.Context, user *User) context.Context {
+	return context.WithValue(ctx, userContextKey, user)
+}
+
+// UserFromContext returns the authenticated user, ok is false for anonymous requests.
+func UserFromContext(ctx context.Context) (*User, bool) {
+	user, ok := ctx.Value(userContextKey).(*User)
+	return user, ok && user != nil
+}
@@ -1,8 +1,34 @@
 package models
 
 import (
+	"encoding/json"
+	"slices"
 	"time"
+)
+
+// Role is the user role kept by the auth service.
+type Role string
 
+const (
+	RoleClient Role = "client"
+	RoleManager Role = "manager"
+	RoleAdmin Role = "admin"
+)
+
+// Roles lists every role an admin may assign.
+var Roles = []Role{RoleClient, RoleManager, RoleAdmin}
+
+func (r Role) Valid() bool {
+	return slices.Contains(Roles, r)
+}
+
+const (
+	BookingCreated = "created"
+	BookingSubmitted = "submitted"
+	BookingCheckedIn = "checked_in"
+	BookingClosed = "closed"
+	BookingCancelled = "cancelled"
+	BookingNoShow = "no_show"
 )
 
 type (
@@ -27,6 +53,17 @@ type (
 		Desc string 	`json:"desc"`
 		City City 		`json:"city"`
 		Tags []Tag 	`json:"tags"`
+		Categories []RoomCategory `json:"categories,omitempty"`
+		ManagerId int64 `json:"manager_id,omitempty"`
+		BaseModel
+	}
+
+	// HotelUpdate holds the hotel fields to change, nil fields are kept
+	HotelUpdate struct {
+		Name *string
+		Desc *string
+		City *string
+		Tags *[]string
 	}
 
 	RoomCategory struct {
@@ -43,6 +80,7 @@ type (
 		Id int64 			`json:"id"`
 		Number string 		`json:"number"`
 		CategoryId int64 	`json:"category_id"`
+		Active bool 		`json:"active"`
 	}
 
 	Booking struct {
@@ -54,6 +92,16 @@ type (
 		GuestsCount int64 	`json:"guests_count"`
 		UserId int64 		`json:"user_id"`
 		RoomId int64 		`json:"room_id"`
+		HotelId int64 		`json:"hotel_id"`
+	}
+
+	HotelTransfer struct {
+		Id int64 				`json:"id"`
+		HotelId int64 			`json:"hotel_id"`
+		FromManagerId int64 	`json:"from_manager_id"`
+		ToManagerId int64 		`json:"to_manager_id"`
+		ActorId int64 			`json:"actor_id"`
+		CreatedAt time.Time 	`json:"created_at"`
 	}
 
 	HotelTag struct {
@@ -62,6 +110,46 @@ type (
 		Tagid int64 		`json:"tag_id"`
 	}
 
+	HotelFilter struct {
+		City string
+		Tags []string
+		AllTags bool
+		Name string
+		PriceFrom float64
+		PriceTo float64
+		Capacity int64
+		Sort string
+		Desc bool
+		Limit int
+		Cursor *HotelCursor
+	}
+
+	// HotelCursor points at the last hotel of a search page
+	HotelCursor struct {
+		Sort string 	`json:"s"`
+		Value string 	`json:"v"`
+		Id int64 		`json:"id"`
+	}
+
+	AvailabilityFilter struct {
+		City string
+		HotelId int64
+		From time.Time
+		To time.Time
+		Guests int64
+	}
+
+	CategoryAvailability struct {
+		Category RoomCategory 	`json:"category"`
+		FreeRooms int64 		`json:"free_rooms"`
+		TotalPrice float64 		`json:"total_price"`
+	}
+
+	HotelAvailability struct {
+		Hotel Hotel 						`json:"hotel"`
+		Categories []CategoryAvailability 	`json:"categories"`
+	}
+
 	User struct {
 		Id int64
 		Username string
@@ -70,6 +158,67 @@ type (
 		Birthday time.Time
 		Email string
 		Password string
-		Role string
+		Role Role
+		Blocked bool
 	}
+
+	// ProfileUpdate holds the profile fields users edit themselves, nil fields are kept.
+	ProfileUpdate struct {
+		FirstName *string
+		LastName *string
+		Email *string
+		Birthday *time.Time
+	}
+
+	UserFilter struct {
+		Query string
+		Role Role
+		Blocked *bool
+		Limit int
+		Offset int
+	}
+
+	// AuditEntry is one mutation, Before and After hold the entity state as json and are
+	// empty for creations and deletions respectively.
+	AuditEntry struct {
+		Id int64 `json:"id"`
+		ActorId int64 `json:"actor_id,omitempty"`
+		Action string `json:"action"`
+		EntityType string `json:"entity_type"`
+		EntityId int64 `json:"entity_id"`
+		Before json.RawMessage `json:"before,omitempty"`
+		After json.RawMessage `json:"after,omitempty"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	AuditFilter struct {
+		ActorId int64
+		EntityType string
+		EntityId int64
+		From *time.Time
+		To *time.Time
+		Limit int
+		Offset int
+	}
+)
+
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+	AuditTransfer = "transfer"
+	AuditChangeStatus = "change_status"
+	AuditChangeRole = "change_role"
+	AuditBlock = "block"
+	AuditUnblock = "unblock"
+)
+
+const (
+	EntityTag = "tag"
+	EntityCity = "city"
+	EntityHotel = "hotel"
+	EntityRoomCategory = "room_category"
+	EntityRoom = "room"
+	EntityBooking = "booking"
+	EntityUser = "user"
 )
\ No newline at end of file
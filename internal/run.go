@@ -2,11 +2,16 @@ package run
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 	"os/signal"
+	"slices"
 	"sync"
 	"syscall"
 
 	"github.com/Bitummit/booking_api/internal/api/rest"
+	"github.com/Bitummit/booking_api/internal/service"
+	"github.com/Bitummit/booking_api/internal/storage/memory"
 	"github.com/Bitummit/booking_api/internal/storage/postgresql"
 	"github.com/Bitummit/booking_api/pkg/config"
 	"github.com/Bitummit/booking_api/pkg/logger"
@@ -22,25 +27,112 @@ func Run() {
 	log := logger.NewLogger()
 	log.Info("Config and logger inited")
 
-	log.Info("Connecting database")
-	storage, err := postgresql.New(ctx)
+	storage, closeStorage, err := openStorage(ctx, cfg, log)
 	if err != nil {
-		log.Error("DB connection %v", logger.Err(err))
+		log.Error("opening storage: ", logger.Err(err))
 		return
 	}
-	log.Info("Database connected")
 
 	wg.Add(1)
 	log.Info("Starting http server")
 	server, err := rest.New(cfg, log, storage)
 	if err != nil {
 		log.Error("starting server: ", logger.Err(err))
-		storage.DB.Close()
+		closeStorage()
 		return
 	}
 	server.Start(ctx, wg)
 
 	<-ctx.Done()
 	wg.Wait()
-	storage.DB.Close()
+	server.AuthService.Close()
+	closeStorage()
+}
+
+// openStorage returns the storage chosen in config and a func that closes it.
+func openStorage(ctx context.Context, cfg *config.Config, log *slog.Logger) (service.HotelStorage, func(), error) {
+	switch cfg.Storage {
+	case "memory":
+		log.Warn("Using in-memory storage, data is lost on exit")
+		return memory.New(), func() {}, nil
+	case "postgres":
+	default:
+		return nil, nil, fmt.Errorf("unknown storage %q", cfg.Storage)
+	}
+
+	log.Info("Connecting database")
+	storage, err := postgresql.New(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connecting database: %w", err)
+	}
+	log.Info("Database connected")
+
+	if cfg.Database.AutoMigrate {
+		versions, err := storage.MigrateUp(ctx)
+		if err != nil {
+			storage.DB.Close()
+			return nil, nil, fmt.Errorf("migrating database: %w", err)
+		}
+		log.Info(fmt.Sprintf("Applied %d migrations", len(versions)))
+	}
+	if err := storage.CheckSchemaVersion(ctx); err != nil {
+		storage.DB.Close()
+		return nil, nil, fmt.Errorf("checking database schema, run the migrate up command: %w", err)
+	}
+	return storage, storage.DB.Close, nil
+}
+
+// Migrate runs the migrate subcommand: up, down, status or redo.
+func Migrate(args []string) error {
+	if len(args) != 1 || !slices.Contains([]string{"up", "down", "status", "redo"}, args[0]) {
+		return fmt.Errorf("usage: migrate up|down|status|redo")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	config.NewConfig()
+	storage, err := postgresql.New(ctx)
+	if err != nil {
+		return err
+	}
+	defer storage.DB.Close()
+
+	switch args[0] {
+	case "up":
+		versions, err := storage.MigrateUp(ctx)
+		if err != nil {
+			return err
+		}
+		for _, version := range versions {
+			fmt.Printf("applied %d\n", version)
+		}
+		if len(versions) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		version, err := storage.MigrateDown(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("rolled back %d\n", version)
+	case "redo":
+		version, err := storage.MigrateRedo(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("redone %d\n", version)
+	case "status":
+		status, err := storage.MigrationStatus(ctx)
+		if err != nil {
+			return err
+		}
+		for _, migration := range status {
+			appliedAt := "pending"
+			if !migration.AppliedAt.IsZero() {
+				appliedAt = migration.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%-20s %s\n", appliedAt, migration.Source.Path)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Bitummit/booking_api/internal/models"
+)
+
+const (
+	DefaultAuditLimit = 100
+	MaxAuditLimit = 1000
+)
+
+// Auditor receives every mutation made through HotelService. New uses the storage, which
+// writes it to the audit log.
+type Auditor interface {
+	RecordAudit(ctx context.Context, entry models.AuditEntry) error
+}
+
+// audit records the mutation on behalf of the calling user. before and after are marshalled
+// to json, nil means there is no state on that side.
+func (s *HotelService) audit(ctx context.Context, action, entityType string, entityID int64, before, after any) error {
+	if s.Auditor == nil {
+		return nil
+	}
+
+	entry := models.AuditEntry{
+		Action: action,
+		EntityType: entityType,
+		EntityId: entityID,
+	}
+	if user, ok := models.UserFromContext(ctx); ok {
+		entry.ActorId = user.Id
+	}
+
+	var err error
+	if entry.Before, err = marshalAuditState(before); err != nil {
+		return fmt.Errorf("recording audit: %w", err)
+	}
+	if entry.After, err = marshalAuditState(after); err != nil {
+		return fmt.Errorf("recording audit: %w", err)
+	}
+
+	if err := s.Auditor.RecordAudit(ctx, entry); err != nil {
+		return fmt.Errorf("recording audit: %w", err)
+	}
+	return nil
+}
+
+func marshalAuditState(state any) (json.RawMessage, error) {
+	if state == nil {
+		return nil, nil
+	}
+	return json.Marshal(state)
+}
+
+func (s *HotelService) ListAudit(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultAuditLimit
+	}
+	filter.Limit = min(filter.Limit, MaxAuditLimit)
+	filter.Offset = max(filter.Offset, 0)
+
+	entries, err := s.Storage.ListAudit(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("listing audit: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,187 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/Bitummit/booking_api/internal/service"
+	"github.com/Bitummit/booking_api/internal/storage/memory"
+)
+
+var errAudit = errors.New("audit log is down")
+
+type failingAuditor struct{}
+
+func (failingAuditor) RecordAudit(context.Context, models.AuditEntry) error {
+	return errAudit
+}
+
+type roles struct {
+	err error
+}
+
+func (r roles) UpdateUserRole(context.Context, models.Role, string) error {
+	return r.err
+}
+
+func newService(t *testing.T) (*service.HotelService, *memory.Storage, context.Context) {
+	t.Helper()
+	storage := memory.New()
+	admin := models.User{Username: "admin", Role: models.RoleAdmin}
+	admin.Id = storage.AddUser(admin)
+	ctx := models.ContextWithUser(context.Background(), &admin)
+	return service.New(storage), storage, ctx
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func auditEntries(t *testing.T, s *service.HotelService, ctx context.Context, entityType string) []models.AuditEntry {
+	t.Helper()
+	entries, err := s.ListAudit(ctx, models.AuditFilter{EntityType: entityType})
+	if err != nil {
+		t.Fatalf("listing audit: %v", err)
+	}
+	return entries
+}
+
+func TestAuditRecordsStoredState(t *testing.T) {
+	s, _, ctx := newService(t)
+
+	tagID, err := s.CreateTag(ctx, models.Tag{Name: "wifi"})
+	if err != nil {
+		t.Fatalf("creating tag: %v", err)
+	}
+	if err := s.DeleteTag(ctx, tagID); err != nil {
+		t.Fatalf("deleting tag: %v", err)
+	}
+	cityID, err := s.CreateCity(ctx, models.City{Name: "Almaty"})
+	if err != nil {
+		t.Fatalf("creating city: %v", err)
+	}
+	if err := s.DeleteCity(ctx, cityID); err != nil {
+		t.Fatalf("deleting city: %v", err)
+	}
+
+	for _, tt := range []struct {
+		entityType string
+		want models.Tag
+	}{
+		{models.EntityTag, models.Tag{Id: tagID, Name: "wifi"}},
+		{models.EntityCity, models.Tag{Id: cityID, Name: "Almaty"}},
+	} {
+		entries := auditEntries(t, s, ctx, tt.entityType)
+		if len(entries) != 2 || entries[0].Action != models.AuditDelete {
+			t.Fatalf("%s audit: got %+v, want a creation and a deletion", tt.entityType, entries)
+		}
+		var before models.Tag
+		if err := json.Unmarshal(entries[0].Before, &before); err != nil {
+			t.Fatalf("decoding %s state: %v", tt.entityType, err)
+		}
+		if before != tt.want {
+			t.Errorf("deleted %s: got %+v, want %+v", tt.entityType, before, tt.want)
+		}
+	}
+}
+
+func TestAuditRecordsRetiredRoom(t *testing.T) {
+	s, storage, ctx := newService(t)
+	guest := models.User{Username: "guest"}
+	guest.Id = storage.AddUser(guest)
+
+	if _, err := s.CreateCity(ctx, models.City{Name: "Almaty"}); err != nil {
+		t.Fatalf("creating city: %v", err)
+	}
+	hotelID, err := s.CreateHotel(ctx, models.Hotel{Name: "Hotel"}, "Almaty", nil)
+	if err != nil {
+		t.Fatalf("creating hotel: %v", err)
+	}
+	categoryID, err := s.CreateRoomCategory(ctx, models.RoomCategory{HotelId: hotelID, Name: "Standard", Price: 100, Capacity: 2})
+	if err != nil {
+		t.Fatalf("creating category: %v", err)
+	}
+	roomID, err := s.CreateRoom(ctx, hotelID, models.Room{Number: "10", CategoryId: categoryID})
+	if err != nil {
+		t.Fatalf("creating room: %v", err)
+	}
+	booking := models.Booking{EntryDate: date(2030, 1, 10), LeaveDate: date(2030, 1, 12), GuestsCount: 1}
+	if _, err := s.CreateBooking(models.ContextWithUser(ctx, &guest), booking, hotelID, categoryID); err != nil {
+		t.Fatalf("booking: %v", err)
+	}
+
+	retired, err := s.DeleteRoom(ctx, hotelID, categoryID, roomID)
+	if err != nil || !retired {
+		t.Fatalf("deleting booked room: got %v, %v, want retired", retired, err)
+	}
+
+	entries := auditEntries(t, s, ctx, models.EntityRoom)
+	if len(entries) != 2 {
+		t.Fatalf("room audit: got %d entries, want 2", len(entries))
+	}
+	var before, after models.Room
+	if err := json.Unmarshal(entries[0].Before, &before); err != nil {
+		t.Fatalf("decoding room state: %v", err)
+	}
+	if err := json.Unmarshal(entries[0].After, &after); err != nil {
+		t.Fatalf("decoding room state: %v", err)
+	}
+	want := models.Room{Id: roomID, Number: "10", CategoryId: categoryID, Active: true}
+	if before != want {
+		t.Errorf("room before deletion: got %+v, want %+v", before, want)
+	}
+	want.Active = false
+	if after != want {
+		t.Errorf("retired room: got %+v, want %+v", after, want)
+	}
+}
+
+func TestAuditFailureRollsBackMutation(t *testing.T) {
+	s, storage, ctx := newService(t)
+	client := models.User{Username: "client"}
+	client.Id = storage.AddUser(client)
+	s.Auditor = failingAuditor{}
+	s.Roles = roles{}
+
+	if id, err := s.CreateTag(ctx, models.Tag{Name: "wifi"}); !errors.Is(err, errAudit) || id != 0 {
+		t.Fatalf("creating tag: got %d, %v, want 0 and the audit error", id, err)
+	}
+	if tags, _ := s.ListTags(ctx); len(tags) != 0 {
+		t.Errorf("tag was stored without its audit entry: %+v", tags)
+	}
+
+	if _, err := s.SetUserBlocked(ctx, client.Id, true); !errors.Is(err, errAudit) {
+		t.Fatalf("blocking user: got %v, want the audit error", err)
+	}
+	if blocked, _ := s.IsUserBlocked(ctx, client.Id); blocked {
+		t.Error("user was blocked without an audit entry")
+	}
+}
+
+func TestChangeUserRoleFailureLeavesNoAudit(t *testing.T) {
+	s, storage, ctx := newService(t)
+	client := models.User{Username: "client"}
+	client.Id = storage.AddUser(client)
+	errAuth := errors.New("auth service is down")
+	s.Roles = roles{err: errAuth}
+
+	if _, err := s.ChangeUserRole(ctx, client.Id, models.RoleManager); !errors.Is(err, errAuth) {
+		t.Fatalf("changing role: got %v, want the auth service error", err)
+	}
+	if entries := auditEntries(t, s, ctx, models.EntityUser); len(entries) != 0 {
+		t.Errorf("failed role change was audited: %+v", entries)
+	}
+
+	s.Roles = roles{}
+	user, err := s.ChangeUserRole(ctx, client.Id, models.RoleManager)
+	if err != nil || user.Role != models.RoleManager {
+		t.Fatalf("changing role: got %+v, %v", user, err)
+	}
+	if entries := auditEntries(t, s, ctx, models.EntityUser); len(entries) != 1 || entries[0].Action != models.AuditChangeRole {
+		t.Errorf("role change audit: got %+v", entries)
+	}
+}
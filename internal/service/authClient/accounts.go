@@ -0,0 +1,64 @@
+package authclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrorNotSupported = errors.New("not supported by auth service")
+	ErrorInvalidPassword = errors.New("invalid password")
+)
+
+// AccountService covers the session calls booking_auth does not expose over grpc yet.
+// Client uses unsupportedAccounts until an implementation is set with SetAccounts.
+type AccountService interface {
+	RefreshToken(ctx context.Context, token string) (string, error)
+	Logout(ctx context.Context, token string) error
+	ChangePassword(ctx context.Context, token, oldPassword, newPassword string) error
+}
+
+type unsupportedAccounts struct{}
+
+func (unsupportedAccounts) RefreshToken(context.Context, string) (string, error) {
+	return "", fmt.Errorf("refreshing token: %w", ErrorNotSupported)
+}
+
+func (unsupportedAccounts) Logout(context.Context, string) error {
+	return fmt.Errorf("logging out: %w", ErrorNotSupported)
+}
+
+func (unsupportedAccounts) ChangePassword(context.Context, string, string, string) error {
+	return fmt.Errorf("changing password: %w", ErrorNotSupported)
+}
+
+func (c *Client) SetAccounts(accounts AccountService) {
+	c.accounts = accounts
+}
+
+// RefreshToken returns a new token, the old one is not served from the cache anymore.
+func (c *Client) RefreshToken(ctx context.Context, token string) (string, error) {
+	newToken, err := c.accounts.RefreshToken(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	c.cache.removeToken(token)
+	return newToken, nil
+}
+
+func (c *Client) Logout(ctx context.Context, token string) error {
+	if err := c.accounts.Logout(ctx, token); err != nil {
+		return err
+	}
+	c.cache.removeToken(token)
+	return nil
+}
+
+func (c *Client) ChangePassword(ctx context.Context, token, oldPassword, newPassword string) error {
+	if err := c.accounts.ChangePassword(ctx, token, oldPassword, newPassword); err != nil {
+		return err
+	}
+	c.cache.removeToken(token)
+	return nil
+}
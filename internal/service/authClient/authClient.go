@@ -2,40 +2,122 @@ package authclient
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/Bitummit/booking_api/internal/models"
 	"github.com/Bitummit/booking_api/pkg/config"
 	auth "github.com/Bitummit/booking_auth/pkg/proto"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
+// Client is safe for concurrent use, one instance is shared by the whole server.
 type Client struct {
 	Client auth.AuthClient
 	Cfg *config.Config
+	conn *grpc.ClientConn
+	cache *userCache
+	verifier *JWTVerifier
+	breaker *breaker
+	accounts AccountService
 }
 
-func New(cfg *config.Config) (*Client, error) {
+const AuthModeJWT = "jwt"
+
+// New dials the auth service at cfg.GrpcAuthAddress. opts are appended to the default
+// dial options, e.g. to dial an in-process server in tests.
+func New(cfg *config.Config, opts ...grpc.DialOption) (*Client, error) {
 	authClient := Client {
 		Cfg: cfg,
+		cache: newUserCache(cfg.UserCacheTTL, cfg.UserCacheSize),
+		breaker: newBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+		accounts: unsupportedAccounts{},
+	}
+
+	if cfg.AuthMode == AuthModeJWT {
+		verifier, err := NewJWTVerifier(cfg.JWTKeyFile, cfg.JWTKeyReload)
+		if err != nil {
+			return nil, fmt.Errorf("creating jwt verifier: %w", err)
+		}
+		authClient.verifier = verifier
 	}
 
-	opts := []grpc.DialOption{
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	dialOpts, err := dialOptions(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("configuring grpc auth client: %w", err)
 	}
-	conn, err := grpc.NewClient(cfg.GrpcAuthAddress, opts...)
+	dialOpts = append(dialOpts, opts...)
+	conn, err := grpc.NewClient(cfg.GrpcAuthAddress, dialOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("creating grpc auth client: %w", err)
 	}
 
 	client := auth.NewAuthClient(conn)
 	authClient.Client = client
+	authClient.conn = conn
 
 	return &authClient, nil
 }
 
-func (c *Client) Registration(user models.User) (string, error) {
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+// BreakerState is one of BreakerClosed, BreakerOpen or BreakerHalfOpen.
+func (c *Client) BreakerState() string {
+	return c.breaker.State()
+}
+
+// call runs one auth service request with the configured deadline. Idempotent requests are
+// retried with exponential backoff while the service is unavailable.
+func (c *Client) call(ctx context.Context, idempotent bool, fn func(ctx context.Context) error) error {
+	attempts := 1
+	if idempotent {
+		attempts += c.Cfg.AuthRetries
+	}
+	backoff := c.Cfg.AuthRetryBackoff
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("%w: %w", ErrorUnavailable, ctx.Err())
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		if !c.breaker.allow() {
+			return fmt.Errorf("%w: circuit breaker is open", ErrorUnavailable)
+		}
+
+		callCtx, cancel := ctx, context.CancelFunc(func() {})
+		if c.Cfg.AuthTimeout > 0 {
+			callCtx, cancel = context.WithTimeout(ctx, c.Cfg.AuthTimeout)
+		}
+		err = mapError(fn(callCtx))
+		cancel()
+
+		if err != nil && ctx.Err() != nil {
+			// the caller gave up, that says nothing about the auth service
+			c.breaker.release()
+			return err
+		}
+		if !errors.Is(err, ErrorUnavailable) {
+			// answers with an error still mean the service is up
+			c.breaker.success()
+			return err
+		}
+		c.breaker.failure()
+	}
+	return err
+}
+
+func (c *Client) Registration(ctx context.Context, user models.User) (string, error) {
 	request := &auth.RegistrationRequest {
 		Username: user.Username,
 		Email: user.Email,
@@ -43,59 +125,93 @@ func (c *Client) Registration(user models.User) (string, error) {
 		FirstName: user.FirstName,
 		LastName: user.LastName,
 	}
-	res, err := c.Client.Registration(context.Background(), request)
+	var res *auth.RegistrationResponse
+	err := c.call(ctx, false, func(ctx context.Context) (err error) {
+		res, err = c.Client.Registration(ctx, request)
+		return err
+	})
 	if err != nil {
-		return "", fmt.Errorf("auth service error: %w", err)
+		return "", err
 	}
 	
 	return res.GetToken(), nil
 }
 
-func (c *Client) Login(user models.User) (string, error) {
+func (c *Client) Login(ctx context.Context, user models.User) (string, error) {
 	request := &auth.LoginRequest {
 		Username: user.Username,
 		Password: user.Password,
 	}
-	res, err := c.Client.Login(context.Background(), request)
+	var res *auth.LoginResponse
+	err := c.call(ctx, false, func(ctx context.Context) (err error) {
+		res, err = c.Client.Login(ctx, request)
+		return err
+	})
 	if err != nil {
-		return "", fmt.Errorf("auth service error: %w", err)
+		return "", err
 	}
 	
 	return res.GetToken(), nil
 }
 
-func (c *Client) CheckIsADmin(token string) error {
+func (c *Client) CheckIsADmin(ctx context.Context, token string) error {
 	request := &auth.CheckTokenRequest {
 		Token: token,
 	}
-	_, err := c.Client.IsAdmin(context.Background(), request)
-	if err != nil {
-		return fmt.Errorf("auth service error: %w", err)
-	}
-	
-	return nil
+	return c.call(ctx, true, func(ctx context.Context) error {
+		_, err := c.Client.IsAdmin(ctx, request)
+		return err
+	})
 }
 
-func (c *Client) UpdateUserRole(role, username string) error {
+func (c *Client) UpdateUserRole(ctx context.Context, role models.Role, username string) error {
 	request := &auth.UpdateUserRoleRequest {
 		Username: username,
-		Role: role,
+		Role: string(role),
 	}
-	_, err := c.Client.UpdateUserRole(context.Background(), request)
+	err := c.call(ctx, false, func(ctx context.Context) error {
+		_, err := c.Client.UpdateUserRole(ctx, request)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("auth service error: %w", err)
+		return err
 	}
+	c.cache.removeUser(username)
 	
 	return nil
 }
 
-func (c *Client) GetUser(token string) (*models.User, error) {
+// ForgetUser drops the cached sessions of the user, e.g. after the profile was changed.
+func (c *Client) ForgetUser(username string) {
+	c.cache.removeUser(username)
+}
+
+func (c *Client) GetUser(ctx context.Context, token string) (*models.User, error) {
+	if c.verifier != nil {
+		user, complete, err := c.verifier.Verify(token)
+		if err != nil {
+			return nil, fmt.Errorf("verifying token: %w", err)
+		}
+		if complete {
+			return user, nil
+		}
+		// signature is fine, but id or role has to come from the auth service
+	}
+
+	if user, ok := c.cache.get(token); ok {
+		return user, nil
+	}
+
 	req := &auth.GetUserRequest {
 		Token: token,
 	}
-	resp, err := c.Client.GetUser(context.Background(), req)
+	var resp *auth.GetUserResponse
+	err := c.call(ctx, true, func(ctx context.Context) (err error) {
+		resp, err = c.Client.GetUser(ctx, req)
+		return err
+	})
 	if err != nil {
-		return nil,fmt.Errorf("auth service error: %w", err)
+		return nil, err
 	}
 	
 	user := models.User{
@@ -104,7 +220,8 @@ func (c *Client) GetUser(token string) (*models.User, error) {
 		FirstName: resp.FirstName,
 		LastName: resp.LastName,
 		Email: resp.Email,
-		Role: resp.Role,
+		Role: models.Role(resp.Role),
 	}
+	c.cache.set(token, user)
 	return &user, nil
-}
\ No newline at end of file
+}
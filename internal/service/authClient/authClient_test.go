@@ -0,0 +1,298 @@
+package authclient_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Bitummit/booking_api/internal/models"
+	authclient "github.com/Bitummit/booking_api/internal/service/authClient"
+	"github.com/Bitummit/booking_api/internal/service/authClient/fakeauth"
+	"github.com/Bitummit/booking_api/pkg/config"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc/codes"
+)
+
+func newClient(t *testing.T, cfg *config.Config) (*fakeauth.Server, *authclient.Client) {
+	t.Helper()
+	fake := fakeauth.Start()
+	t.Cleanup(fake.Close)
+	if cfg == nil {
+		cfg = &config.Config{}
+		cfg.UserCacheTTL = time.Minute
+		cfg.UserCacheSize = 100
+	}
+	client, err := fake.Client(cfg)
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return fake, client
+}
+
+func TestGetUserSharesConnection(t *testing.T) {
+	fake, client := newClient(t, nil)
+	ctx := context.Background()
+
+	const users = 10
+	tokens := make([]string, users)
+	for i := range tokens {
+		tokens[i] = fake.AddUser(models.User{Username: fmt.Sprintf("user%d", i)}, "secret", "")
+	}
+	for round := 0; round < 3; round++ {
+		for i, token := range tokens {
+			user, err := client.GetUser(ctx, token)
+			if err != nil {
+				t.Fatalf("getting user %d: %v", i, err)
+			}
+			if want := fmt.Sprintf("user%d", i); user.Username != want {
+				t.Fatalf("user = %q, want %q", user.Username, want)
+			}
+		}
+	}
+
+	if dials := fake.Dials(); dials != 1 {
+		t.Errorf("client dialed %d times, want 1", dials)
+	}
+	// only the first round misses the cache
+	if calls := fake.Calls("GetUser"); calls != users {
+		t.Errorf("auth service got %d GetUser calls, want %d", calls, users)
+	}
+}
+
+func TestGetUserCacheExpires(t *testing.T) {
+	fake, client := newClient(t, nil)
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	client.SetNow(func() time.Time { return now })
+
+	token := fake.AddUser(models.User{Username: "alice"}, "secret", "")
+	get := func() {
+		t.Helper()
+		if _, err := client.GetUser(ctx, token); err != nil {
+			t.Fatalf("getting user: %v", err)
+		}
+	}
+
+	get()
+	now = now.Add(30 * time.Second)
+	get()
+	if calls := fake.Calls("GetUser"); calls != 1 {
+		t.Fatalf("GetUser calls within the ttl = %d, want 1", calls)
+	}
+
+	now = now.Add(31 * time.Second)
+	get()
+	if calls := fake.Calls("GetUser"); calls != 2 {
+		t.Fatalf("GetUser calls after the ttl = %d, want 2", calls)
+	}
+}
+
+func TestGetUserCacheSize(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.UserCacheTTL = time.Minute
+	cfg.UserCacheSize = 2
+	fake, client := newClient(t, cfg)
+	ctx := context.Background()
+
+	first := fake.AddUser(models.User{Username: "first"}, "secret", "")
+	for _, token := range []string{
+		first,
+		fake.AddUser(models.User{Username: "second"}, "secret", ""),
+		fake.AddUser(models.User{Username: "third"}, "secret", ""),
+		first,
+	} {
+		if _, err := client.GetUser(ctx, token); err != nil {
+			t.Fatalf("getting user: %v", err)
+		}
+	}
+	// the third user pushed the first one out of the cache
+	if calls := fake.Calls("GetUser"); calls != 4 {
+		t.Errorf("GetUser calls = %d, want 4", calls)
+	}
+}
+
+func TestGetUserInvalidToken(t *testing.T) {
+	_, client := newClient(t, nil)
+
+	_, err := client.GetUser(context.Background(), "unknown")
+	if !errors.Is(err, authclient.ErrorInvalidToken) {
+		t.Fatalf("error = %v, want ErrorInvalidToken", err)
+	}
+}
+
+func TestUpdateUserRoleDropsCachedUser(t *testing.T) {
+	fake, client := newClient(t, nil)
+	ctx := context.Background()
+
+	token := fake.AddUser(models.User{Username: "alice"}, "secret", models.RoleClient)
+	other := "other-session"
+	fake.AddToken(other, "alice")
+	for _, tok := range []string{token, other} {
+		if _, err := client.GetUser(ctx, tok); err != nil {
+			t.Fatalf("getting user: %v", err)
+		}
+	}
+
+	if err := client.UpdateUserRole(ctx, models.RoleManager, "alice"); err != nil {
+		t.Fatalf("updating role: %v", err)
+	}
+	// every session of the user sees the new role at once
+	for _, tok := range []string{token, other} {
+		user, err := client.GetUser(ctx, tok)
+		if err != nil {
+			t.Fatalf("getting user: %v", err)
+		}
+		if user.Role != models.RoleManager {
+			t.Errorf("role = %q, want %q", user.Role, models.RoleManager)
+		}
+	}
+}
+
+func TestLogoutDropsCachedToken(t *testing.T) {
+	fake, client := newClient(t, nil)
+	client.SetAccounts(fake.Accounts())
+	ctx := context.Background()
+
+	token := fake.AddUser(models.User{Username: "alice"}, "secret", "")
+	if _, err := client.GetUser(ctx, token); err != nil {
+		t.Fatalf("getting user: %v", err)
+	}
+	if err := client.Logout(ctx, token); err != nil {
+		t.Fatalf("logging out: %v", err)
+	}
+	if _, err := client.GetUser(ctx, token); !errors.Is(err, authclient.ErrorInvalidToken) {
+		t.Fatalf("error after logout = %v, want ErrorInvalidToken", err)
+	}
+}
+
+func TestAccountsNotSupported(t *testing.T) {
+	fake, client := newClient(t, nil)
+	ctx := context.Background()
+
+	token := fake.AddUser(models.User{Username: "alice"}, "secret", "")
+	if _, err := client.RefreshToken(ctx, token); !errors.Is(err, authclient.ErrorNotSupported) {
+		t.Errorf("refreshing token = %v, want ErrorNotSupported", err)
+	}
+	if err := client.Logout(ctx, token); !errors.Is(err, authclient.ErrorNotSupported) {
+		t.Errorf("logging out = %v, want ErrorNotSupported", err)
+	}
+	if err := client.ChangePassword(ctx, token, "secret", "new-secret"); !errors.Is(err, authclient.ErrorNotSupported) {
+		t.Errorf("changing password = %v, want ErrorNotSupported", err)
+	}
+	// the token still works, nothing was dropped
+	if _, err := client.GetUser(ctx, token); err != nil {
+		t.Fatalf("getting user: %v", err)
+	}
+}
+
+func TestGetUserJWT(t *testing.T) {
+	secret := []byte("shared-secret")
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, secret, 0o600); err != nil {
+		t.Fatalf("writing key file: %v", err)
+	}
+	cfg := &config.Config{}
+	cfg.AuthMode = authclient.AuthModeJWT
+	cfg.JWTKeyFile = path
+	cfg.JWTKeyReload = time.Hour
+	cfg.UserCacheTTL = time.Minute
+	cfg.UserCacheSize = 100
+	fake, client := newClient(t, cfg)
+	ctx := context.Background()
+
+	sign := func(claims jwt.MapClaims) string {
+		t.Helper()
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+		if err != nil {
+			t.Fatalf("signing token: %v", err)
+		}
+		return token
+	}
+
+	complete := sign(jwt.MapClaims{"Id": 1, "Username": "bob", "Role": "admin"})
+	user, err := client.GetUser(ctx, complete)
+	if err != nil {
+		t.Fatalf("getting user from complete claims: %v", err)
+	}
+	if user.Username != "bob" || user.Role != models.RoleAdmin {
+		t.Errorf("user = %+v, want admin bob", *user)
+	}
+	if calls := fake.Calls("GetUser"); calls != 0 {
+		t.Errorf("complete claims caused %d GetUser calls, want 0", calls)
+	}
+
+	// tokens of booking_auth have no role, it comes from the auth service
+	fake.AddUser(models.User{Username: "alice"}, "secret", models.RoleManager)
+	incomplete := sign(jwt.MapClaims{"Id": 1, "Username": "alice", "ExpiresAt": time.Now().Add(time.Hour).Unix()})
+	fake.AddToken(incomplete, "alice")
+	for i := 0; i < 2; i++ {
+		user, err = client.GetUser(ctx, incomplete)
+		if err != nil {
+			t.Fatalf("getting user from incomplete claims: %v", err)
+		}
+		if user.Role != models.RoleManager {
+			t.Errorf("role = %q, want %q", user.Role, models.RoleManager)
+		}
+	}
+	if calls := fake.Calls("GetUser"); calls != 1 {
+		t.Errorf("incomplete claims caused %d GetUser calls, want 1", calls)
+	}
+
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"Id": 1, "Role": "admin"}).SignedString([]byte("guess"))
+	if _, err := client.GetUser(ctx, forged); !errors.Is(err, authclient.ErrorInvalidToken) {
+		t.Errorf("error for a forged token = %v, want ErrorInvalidToken", err)
+	}
+}
+
+func TestCanceledCallKeepsBreakerState(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.BreakerThreshold = 2
+	cfg.BreakerCooldown = 50 * time.Millisecond
+	fake, client := newClient(t, cfg)
+	token := fake.AddUser(models.User{Username: "alice"}, "secret", "")
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	getUser := func(ctx context.Context) error {
+		_, err := client.GetUser(ctx, token)
+		return err
+	}
+
+	// the service is down, a canceled call between two failures does not reset their count
+	fake.FailWith(codes.Unavailable)
+	if err := getUser(context.Background()); !errors.Is(err, authclient.ErrorUnavailable) {
+		t.Fatalf("first failure = %v, want ErrorUnavailable", err)
+	}
+	if err := getUser(canceled); err == nil {
+		t.Fatal("canceled call succeeded")
+	}
+	if err := getUser(context.Background()); !errors.Is(err, authclient.ErrorUnavailable) {
+		t.Fatalf("second failure = %v, want ErrorUnavailable", err)
+	}
+	if state := client.BreakerState(); state != authclient.BreakerOpen {
+		t.Fatalf("breaker after two failures = %s, want open", state)
+	}
+
+	// a canceled probe neither closes the half-open breaker nor keeps its probe slot
+	time.Sleep(cfg.BreakerCooldown)
+	if err := getUser(canceled); err == nil {
+		t.Fatal("canceled probe succeeded")
+	}
+	if state := client.BreakerState(); state != authclient.BreakerHalfOpen {
+		t.Fatalf("breaker after a canceled probe = %s, want half-open", state)
+	}
+	calls := fake.Calls("GetUser")
+	if err := getUser(context.Background()); !errors.Is(err, authclient.ErrorUnavailable) {
+		t.Fatalf("probe = %v, want ErrorUnavailable", err)
+	}
+	if fake.Calls("GetUser") != calls+1 {
+		t.Fatal("the probe after a canceled one did not reach the auth service")
+	}
+	if state := client.BreakerState(); state != authclient.BreakerOpen {
+		t.Fatalf("breaker after a failed probe = %s, want open", state)
+	}
+}
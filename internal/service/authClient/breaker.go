@@ -0,0 +1,101 @@
+package authclient
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	BreakerClosed = "closed"
+	BreakerOpen = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// breaker stops calling the auth service after threshold failures in a row. After cooldown
+// one probe call is let through, its result decides whether the breaker closes again.
+// A zero threshold disables it.
+type breaker struct {
+	mu sync.Mutex
+	threshold int
+	cooldown time.Duration
+	failures int
+	state string
+	openedAt time.Time
+	probing bool
+	now func() time.Time
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{
+		threshold: threshold,
+		cooldown: cooldown,
+		state: BreakerClosed,
+		now: time.Now,
+	}
+}
+
+// allow reports whether a call may go to the auth service.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+	b.state = BreakerClosed
+}
+
+func (b *breaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.threshold <= 0 {
+		return
+	}
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// release ends a call without a verdict about the service health.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+func (b *breaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
@@ -0,0 +1,108 @@
+package authclient
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/Bitummit/booking_api/internal/models"
+)
+
+// userCache is a size bounded LRU of token -> user with a TTL per entry.
+type userCache struct {
+	mu sync.Mutex
+	ttl time.Duration
+	size int
+	items map[string]*list.Element
+	order *list.List
+	now func() time.Time
+}
+
+type cacheEntry struct {
+	token string
+	user models.User
+	expires time.Time
+}
+
+func newUserCache(ttl time.Duration, size int) *userCache {
+	return &userCache{
+		ttl: ttl,
+		size: size,
+		items: make(map[string]*list.Element),
+		order: list.New(),
+		now: time.Now,
+	}
+}
+
+func (c *userCache) get(token string) (*models.User, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[token]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if c.now().After(entry.expires) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+
+	// callers get their own copy, cached users are never shared between requests
+	user := entry.user
+	return &user, true
+}
+
+func (c *userCache) set(token string, user models.User) {
+	if c.size <= 0 || c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[token]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.user = user
+		entry.expires = c.now().Add(c.ttl)
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[token] = c.order.PushFront(&cacheEntry{
+		token: token,
+		user: user,
+		expires: c.now().Add(c.ttl),
+	})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+// removeUser drops every token of the user, e.g. after the role was changed.
+func (c *userCache) removeUser(username string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		if elem.Value.(*cacheEntry).user.Username == username {
+			c.remove(elem)
+		}
+		elem = next
+	}
+}
+
+func (c *userCache) removeToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[token]; ok {
+		c.remove(elem)
+	}
+}
+
+func (c *userCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*cacheEntry).token)
+}
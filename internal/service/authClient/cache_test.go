@@ -0,0 +1,141 @@
+package authclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Bitummit/booking_api/internal/models"
+)
+
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func newTestCache(ttl time.Duration, size int) (*userCache, *clock) {
+	clk := &clock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	cache := newUserCache(ttl, size)
+	cache.now = clk.now
+	return cache, clk
+}
+
+func TestCacheGet(t *testing.T) {
+	cache, _ := newTestCache(time.Minute, 10)
+	cache.set("token", models.User{Id: 1, Username: "alice", Role: models.RoleClient})
+
+	user, ok := cache.get("token")
+	if !ok || user.Username != "alice" {
+		t.Fatalf("get = %+v, %v, want alice", user, ok)
+	}
+	// callers get a copy
+	user.Role = models.RoleAdmin
+	if user, _ := cache.get("token"); user.Role != models.RoleClient {
+		t.Errorf("cached role = %q after changing the returned user", user.Role)
+	}
+	if _, ok := cache.get("other"); ok {
+		t.Error("get of an unknown token hit")
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	cache, clk := newTestCache(time.Minute, 10)
+	cache.set("token", models.User{Username: "alice"})
+
+	clk.t = clk.t.Add(time.Minute)
+	if _, ok := cache.get("token"); !ok {
+		t.Fatal("entry expired before its ttl")
+	}
+	clk.t = clk.t.Add(time.Second)
+	if _, ok := cache.get("token"); ok {
+		t.Fatal("entry served after its ttl")
+	}
+	if len(cache.items) != 0 || cache.order.Len() != 0 {
+		t.Errorf("expired entry kept: %d items, %d in order", len(cache.items), cache.order.Len())
+	}
+
+	// set refreshes the expiration of an existing entry
+	cache.set("token", models.User{Username: "alice"})
+	clk.t = clk.t.Add(50 * time.Second)
+	cache.set("token", models.User{Username: "alice"})
+	clk.t = clk.t.Add(50 * time.Second)
+	if _, ok := cache.get("token"); !ok {
+		t.Error("entry expired although set refreshed it")
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache, _ := newTestCache(time.Minute, 2)
+	cache.set("a", models.User{Username: "a"})
+	cache.set("b", models.User{Username: "b"})
+	// a becomes the most recently used entry, so b is evicted by c
+	cache.get("a")
+	cache.set("c", models.User{Username: "c"})
+
+	if _, ok := cache.get("b"); ok {
+		t.Error("least recently used entry was not evicted")
+	}
+	for _, token := range []string{"a", "c"} {
+		if _, ok := cache.get(token); !ok {
+			t.Errorf("entry %s was evicted", token)
+		}
+	}
+	if len(cache.items) != 2 {
+		t.Errorf("cache holds %d items, want 2", len(cache.items))
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		ttl time.Duration
+		size int
+	}{
+		{"zero size", time.Minute, 0},
+		{"zero ttl", 0, 10},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, _ := newTestCache(tt.ttl, tt.size)
+			cache.set("token", models.User{Username: "alice"})
+			if _, ok := cache.get("token"); ok {
+				t.Error("disabled cache returned an entry")
+			}
+		})
+	}
+}
+
+func TestCacheRemoveUser(t *testing.T) {
+	cache, _ := newTestCache(time.Minute, 10)
+	cache.set("alice-1", models.User{Username: "alice"})
+	cache.set("bob", models.User{Username: "bob"})
+	cache.set("alice-2", models.User{Username: "alice"})
+
+	cache.removeUser("alice")
+
+	for _, token := range []string{"alice-1", "alice-2"} {
+		if _, ok := cache.get(token); ok {
+			t.Errorf("token %s of the removed user is still cached", token)
+		}
+	}
+	if _, ok := cache.get("bob"); !ok {
+		t.Error("token of another user was removed")
+	}
+}
+
+func TestCacheRemoveToken(t *testing.T) {
+	cache, _ := newTestCache(time.Minute, 10)
+	cache.set("old", models.User{Username: "alice"})
+	cache.set("new", models.User{Username: "alice"})
+
+	cache.removeToken("old")
+	cache.removeToken("unknown")
+
+	if _, ok := cache.get("old"); ok {
+		t.Error("removed token is still cached")
+	}
+	if _, ok := cache.get("new"); !ok {
+		t.Error("other token of the user was removed")
+	}
+}
@@ -0,0 +1,35 @@
+package authclient
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrorInvalidToken = errors.New("invalid token")
+	ErrorPermissionDenied = errors.New("permission denied")
+	ErrorUnavailable = errors.New("auth service unavailable")
+	ErrorAlreadyExists = errors.New("user already exists")
+)
+
+// mapError turns grpc status codes of the auth service into errors the api layer can check with errors.Is.
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch status.Code(err) {
+	case codes.Unauthenticated, codes.NotFound, codes.InvalidArgument:
+		return fmt.Errorf("auth service error: %w: %w", ErrorInvalidToken, err)
+	case codes.PermissionDenied:
+		return fmt.Errorf("auth service error: %w: %w", ErrorPermissionDenied, err)
+	case codes.AlreadyExists:
+		return fmt.Errorf("auth service error: %w: %w", ErrorAlreadyExists, err)
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return fmt.Errorf("auth service error: %w: %w", ErrorUnavailable, err)
+	default:
+		return fmt.Errorf("auth service error: %w", err)
+	}
+}
@@ -0,0 +1,11 @@
+package authclient
+
+import "time"
+
+// SetNow replaces the clock of the user cache.
+func (c *Client) SetNow(now func() time.Time) {
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+
+	c.cache.now = now
+}
@@ -0,0 +1,73 @@
+package fakeauth
+
+import (
+	"context"
+	"fmt"
+
+	authclient "github.com/Bitummit/booking_api/internal/service/authClient"
+)
+
+// Accounts implements authclient.AccountService on the fake's users and tokens, set it on
+// a client with SetAccounts. Clients built by Client keep the unsupported default.
+func (s *Server) Accounts() authclient.AccountService {
+	return accounts{s}
+}
+
+type accounts struct {
+	s *Server
+}
+
+func (a accounts) RefreshToken(_ context.Context, token string) (string, error) {
+	a.s.mu.Lock()
+	defer a.s.mu.Unlock()
+
+	acc, err := a.account(token)
+	if err != nil {
+		return "", err
+	}
+	delete(a.s.tokens, token)
+	return a.s.issueToken(acc.user.Username), nil
+}
+
+func (a accounts) Logout(_ context.Context, token string) error {
+	a.s.mu.Lock()
+	defer a.s.mu.Unlock()
+
+	if _, err := a.account(token); err != nil {
+		return err
+	}
+	delete(a.s.tokens, token)
+	return nil
+}
+
+func (a accounts) ChangePassword(_ context.Context, token, oldPassword, newPassword string) error {
+	a.s.mu.Lock()
+	defer a.s.mu.Unlock()
+
+	acc, err := a.account(token)
+	if err != nil {
+		return err
+	}
+	if acc.password != oldPassword {
+		return fmt.Errorf("changing password: %w", authclient.ErrorInvalidPassword)
+	}
+	acc.password = newPassword
+	// other sessions of the user end with the password change
+	for t, username := range a.s.tokens {
+		if username == acc.user.Username && t != token {
+			delete(a.s.tokens, t)
+		}
+	}
+	return nil
+}
+
+func (a accounts) account(token string) (*account, error) {
+	if err := a.s.injected(); err != nil {
+		return nil, err
+	}
+	username, ok := a.s.tokens[token]
+	if !ok {
+		return nil, authclient.ErrorInvalidToken
+	}
+	return a.s.accounts[username], nil
+}
@@ -0,0 +1,314 @@
+// Package fakeauth serves an in-memory booking_auth gRPC service over bufconn, so the auth
+// client, middlewares and REST routes can be exercised in go test without the real service.
+package fakeauth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"path"
+	"sync"
+
+	"github.com/Bitummit/booking_api/internal/models"
+	authclient "github.com/Bitummit/booking_api/internal/service/authClient"
+	"github.com/Bitummit/booking_api/pkg/config"
+	auth "github.com/Bitummit/booking_auth/pkg/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const bufSize = 1024 * 1024
+
+// Address is the target to put into config.GrpcAuthAddress, DialOptions route it to the fake.
+const Address = "passthrough:///fakeauth"
+
+type account struct {
+	user models.User
+	password string
+}
+
+// Server is a fake auth.AuthServer. Users and tokens are kept in memory, a user registered
+// or logged in gets a new random token.
+type Server struct {
+	auth.UnimplementedAuthServer
+
+	mu sync.Mutex
+	nextID int64
+	accounts map[string]*account
+	tokens map[string]string
+	failure codes.Code
+	dials int
+	calls map[string]int
+
+	listener *bufconn.Listener
+	grpc *grpc.Server
+}
+
+// Start serves a fake with no users. Stop it with Close.
+func Start() *Server {
+	s := &Server{
+		accounts: make(map[string]*account),
+		tokens: make(map[string]string),
+		calls: make(map[string]int),
+		listener: bufconn.Listen(bufSize),
+	}
+	s.grpc = grpc.NewServer(grpc.UnaryInterceptor(s.count))
+	auth.RegisterAuthServer(s.grpc, s)
+	go s.grpc.Serve(s.listener)
+	return s
+}
+
+func (s *Server) Close() {
+	s.grpc.Stop()
+	s.listener.Close()
+}
+
+func (s *Server) DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			s.mu.Lock()
+			s.dials++
+			s.mu.Unlock()
+			return s.listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+}
+
+// Client builds an authclient.Client connected to the fake, cfg may be nil.
+func (s *Server) Client(cfg *config.Config) (*authclient.Client, error) {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	cfgCopy := *cfg
+	cfgCopy.GrpcAuthAddress = Address
+	return authclient.New(&cfgCopy, s.DialOptions()...)
+}
+
+// AddUser registers a user with the role and returns a valid token for it.
+// An empty role means models.RoleClient.
+func (s *Server) AddUser(user models.User, password string, role models.Role) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if role == "" {
+		role = models.RoleClient
+	}
+	s.nextID++
+	user.Id = s.nextID
+	user.Role = role
+	user.Password = ""
+	s.accounts[user.Username] = &account{user: user, password: password}
+	return s.issueToken(user.Username)
+}
+
+// AddToken makes token valid for an existing user.
+func (s *Server) AddToken(token, username string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[token] = username
+}
+
+func (s *Server) RevokeToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tokens, token)
+}
+
+// User returns the stored user, the role reflects UpdateUserRole calls.
+func (s *Server) User(username string) (models.User, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	acc, ok := s.accounts[username]
+	if !ok {
+		return models.User{}, false
+	}
+	return acc.user, true
+}
+
+// Dials is the number of connections clients of the fake opened.
+func (s *Server) Dials() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.dials
+}
+
+// Calls is the number of requests served for the method, e.g. "GetUser".
+func (s *Server) Calls(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls[method]
+}
+
+// FailWith makes every call fail with the code until it is reset with codes.OK,
+// e.g. codes.Unavailable to exercise retries and the circuit breaker.
+func (s *Server) FailWith(code codes.Code) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failure = code
+}
+
+func (s *Server) Login(_ context.Context, req *auth.LoginRequest) (*auth.LoginResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.injected(); err != nil {
+		return nil, err
+	}
+	acc, ok := s.accounts[req.GetUsername()]
+	if !ok || acc.password != req.GetPassword() {
+		return nil, status.Error(codes.Unauthenticated, "wrong username or password")
+	}
+	return &auth.LoginResponse{Token: s.issueToken(acc.user.Username)}, nil
+}
+
+func (s *Server) Registration(_ context.Context, req *auth.RegistrationRequest) (*auth.RegistrationResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.injected(); err != nil {
+		return nil, err
+	}
+	if req.GetUsername() == "" || req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "username and password are required")
+	}
+	if _, ok := s.accounts[req.GetUsername()]; ok {
+		return nil, status.Error(codes.AlreadyExists, "user already exists")
+	}
+	s.nextID++
+	user := models.User{
+		Id: s.nextID,
+		Username: req.GetUsername(),
+		FirstName: req.GetFirstName(),
+		LastName: req.GetLastName(),
+		Email: req.GetEmail(),
+		Role: models.RoleClient,
+	}
+	s.accounts[user.Username] = &account{user: user, password: req.GetPassword()}
+	return &auth.RegistrationResponse{Token: s.issueToken(user.Username)}, nil
+}
+
+func (s *Server) CheckToken(_ context.Context, req *auth.CheckTokenRequest) (*auth.EmptyResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.userByToken(req.GetToken()); err != nil {
+		return nil, err
+	}
+	return &auth.EmptyResponse{}, nil
+}
+
+func (s *Server) CheckRole(_ context.Context, req *auth.CheckRoleRequest) (*auth.CheckRoleResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, err := s.userByToken(req.GetToken())
+	if err != nil {
+		return nil, err
+	}
+	return &auth.CheckRoleResponse{Role: string(user.Role)}, nil
+}
+
+func (s *Server) IsAdmin(_ context.Context, req *auth.CheckTokenRequest) (*auth.EmptyResponse, error) {
+	return s.hasRole(req.GetToken(), models.RoleAdmin)
+}
+
+func (s *Server) IsManager(_ context.Context, req *auth.CheckTokenRequest) (*auth.EmptyResponse, error) {
+	return s.hasRole(req.GetToken(), models.RoleManager)
+}
+
+func (s *Server) UpdateUserRole(_ context.Context, req *auth.UpdateUserRoleRequest) (*auth.EmptyResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.injected(); err != nil {
+		return nil, err
+	}
+	acc, ok := s.accounts[req.GetUsername()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	acc.user.Role = models.Role(req.GetRole())
+	return &auth.EmptyResponse{}, nil
+}
+
+func (s *Server) GetUser(_ context.Context, req *auth.GetUserRequest) (*auth.GetUserResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, err := s.userByToken(req.GetToken())
+	if err != nil {
+		return nil, err
+	}
+	return &auth.GetUserResponse{
+		Id: user.Id,
+		Username: user.Username,
+		FirstName: user.FirstName,
+		LastName: user.LastName,
+		Email: user.Email,
+		Role: string(user.Role),
+	}, nil
+}
+
+func (s *Server) hasRole(token string, role models.Role) (*auth.EmptyResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, err := s.userByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role != role {
+		return nil, status.Error(codes.PermissionDenied, "no enough permission")
+	}
+	return &auth.EmptyResponse{}, nil
+}
+
+func (s *Server) count(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	s.mu.Lock()
+	s.calls[path.Base(info.FullMethod)]++
+	s.mu.Unlock()
+	return handler(ctx, req)
+}
+
+// userByToken and the helpers below expect s.mu to be held.
+func (s *Server) userByToken(token string) (models.User, error) {
+	if err := s.injected(); err != nil {
+		return models.User{}, err
+	}
+	username, ok := s.tokens[token]
+	if !ok {
+		return models.User{}, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	acc, ok := s.accounts[username]
+	if !ok {
+		return models.User{}, status.Error(codes.NotFound, "user not found")
+	}
+	return acc.user, nil
+}
+
+func (s *Server) injected() error {
+	if s.failure == codes.OK {
+		return nil
+	}
+	return status.Error(s.failure, fmt.Sprintf("injected %s", s.failure))
+}
+
+func (s *Server) issueToken(username string) string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	token := hex.EncodeToString(b)
+	s.tokens[token] = username
+	return token
+}
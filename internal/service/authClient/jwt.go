@@ -0,0 +1,304 @@
+package authclient
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var jwtMethods = []string{"HS256", "HS384", "HS512", "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// JWTVerifier checks bearer tokens against the keys from a PEM, JWKS or HMAC secret file.
+// The file is re-read when it changes on disk, so keys can be rotated without a restart.
+type JWTVerifier struct {
+	path string
+	reloadInterval time.Duration
+
+	mu sync.RWMutex
+	keys map[string]crypto.PublicKey
+	modTime time.Time
+	checkedAt time.Time
+}
+
+func NewJWTVerifier(path string, reloadInterval time.Duration) (*JWTVerifier, error) {
+	v := &JWTVerifier{
+		path: path,
+		reloadInterval: reloadInterval,
+	}
+	if err := v.reload(); err != nil {
+		return nil, fmt.Errorf("loading jwt keys: %w", err)
+	}
+	return v, nil
+}
+
+// Verify returns the user from the token claims. complete is false when the claims lack the
+// user id or role and the user has to be asked from the auth service.
+func (v *JWTVerifier) Verify(token string) (user *models.User, complete bool, err error) {
+	v.reloadIfChanged(false)
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(token, claims, v.keyFunc, jwt.WithValidMethods(jwtMethods))
+	if errors.Is(err, errUnknownKey) {
+		// the token may be signed with a key added after the last reload
+		v.reloadIfChanged(true)
+		_, err = jwt.ParseWithClaims(token, claims, v.keyFunc, jwt.WithValidMethods(jwtMethods))
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %w", ErrorInvalidToken, err)
+	}
+	// tokens of the auth service carry their own expiration claim
+	if exp, ok := numberClaim(claims, "ExpiresAt"); ok && exp < time.Now().Unix() {
+		return nil, false, fmt.Errorf("%w: token expired", ErrorInvalidToken)
+	}
+
+	user = &models.User{}
+	user.Id, _ = numberClaim(claims, "Id", "user_id", "sub")
+	user.Username = stringClaim(claims, "Username", "username", "preferred_username")
+	user.Role = models.Role(stringClaim(claims, "Role", "role"))
+	user.Email = stringClaim(claims, "Email", "email")
+	user.FirstName = stringClaim(claims, "FirstName", "first_name", "given_name")
+	user.LastName = stringClaim(claims, "LastName", "last_name", "family_name")
+
+	return user, user.Id != 0 && user.Role != "", nil
+}
+
+var errUnknownKey = errors.New("unknown signing key")
+
+func (v *JWTVerifier) keyFunc(token *jwt.Token) (any, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if kid, ok := token.Header["kid"].(string); ok && kid != "" {
+		key, ok := v.keys[kid]
+		if !ok {
+			return nil, errUnknownKey
+		}
+		return key, nil
+	}
+
+	set := jwt.VerificationKeySet{}
+	for _, key := range v.keys {
+		set.Keys = append(set.Keys, key)
+	}
+	return set, nil
+}
+
+func (v *JWTVerifier) reloadIfChanged(force bool) {
+	v.mu.RLock()
+	due := force || time.Since(v.checkedAt) >= v.reloadInterval
+	v.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	// a broken key file keeps the previous keys in use
+	_ = v.reload()
+}
+
+func (v *JWTVerifier) reload() error {
+	info, err := os.Stat(v.path)
+	if err != nil {
+		return fmt.Errorf("reading key file: %w", err)
+	}
+
+	v.mu.RLock()
+	unchanged := v.keys != nil && info.ModTime().Equal(v.modTime)
+	v.mu.RUnlock()
+	if unchanged {
+		v.mu.Lock()
+		v.checkedAt = time.Now()
+		v.mu.Unlock()
+		return nil
+	}
+
+	data, err := os.ReadFile(v.path)
+	if err != nil {
+		return fmt.Errorf("reading key file: %w", err)
+	}
+	keys, err := parseKeys(data)
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.modTime = info.ModTime()
+	v.checkedAt = time.Now()
+	v.mu.Unlock()
+	return nil
+}
+
+// parseKeys accepts a JWKS document, one or more PEM public keys or certificates, or the
+// shared HMAC secret booking_auth signs its tokens with.
+func parseKeys(data []byte) (map[string]crypto.PublicKey, error) {
+	keys := make(map[string]crypto.PublicKey)
+
+	switch trimmed := bytes.TrimSpace(data); {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		var set jwks
+		if err := json.Unmarshal(trimmed, &set); err != nil {
+			return nil, fmt.Errorf("parsing jwks: %w", err)
+		}
+		for i, jwk := range set.Keys {
+			key, err := jwk.publicKey()
+			if err != nil {
+				return nil, fmt.Errorf("parsing jwk %d: %w", i, err)
+			}
+			kid := jwk.Kid
+			if kid == "" {
+				kid = strconv.Itoa(i)
+			}
+			keys[kid] = key
+		}
+	case bytes.Contains(trimmed, []byte("-----BEGIN")):
+		for i := 0; ; i++ {
+			var block *pem.Block
+			block, trimmed = pem.Decode(trimmed)
+			if block == nil {
+				break
+			}
+			key, err := parsePEMBlock(block)
+			if err != nil {
+				return nil, fmt.Errorf("parsing pem block %d: %w", i, err)
+			}
+			keys[strconv.Itoa(i)] = key
+		}
+	case len(trimmed) > 0:
+		// hmac keys are []byte, so the hs methods never accept one of the public keys as secret
+		keys["0"] = trimmed
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no keys found")
+	}
+	return keys, nil
+}
+
+func parsePEMBlock(block *pem.Block) (crypto.PublicKey, error) {
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	}
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv"`
+	N string `json:"n"`
+	E string `json:"e"`
+	X string `json:"x"`
+	Y string `json:"y"`
+	K string `json:"k"`
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curves := map[string]elliptic.Curve{
+			"P-256": elliptic.P256(),
+			"P-384": elliptic.P384(),
+			"P-521": elliptic.P521(),
+		}
+		curve, ok := curves[k.Crv]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil || len(secret) == 0 {
+			return nil, fmt.Errorf("invalid hmac key")
+		}
+		return secret, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func stringClaim(claims jwt.MapClaims, names ...string) string {
+	for _, name := range names {
+		if value, ok := claims[name].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func numberClaim(claims jwt.MapClaims, names ...string) (int64, bool) {
+	for _, name := range names {
+		switch value := claims[name].(type) {
+		case float64:
+			return int64(value), true
+		case string:
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+				return n, true
+			}
+		}
+	}
+	return 0, false
+}
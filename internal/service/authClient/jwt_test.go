@@ -0,0 +1,297 @@
+package authclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func rsaKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating rsa key: %v", err)
+	}
+	return key
+}
+
+func pemBlock(t *testing.T, typ string, der []byte) []byte {
+	t.Helper()
+	return pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+}
+
+func publicPEM(t *testing.T, pub any) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatalf("marshaling public key: %v", err)
+	}
+	return pemBlock(t, "PUBLIC KEY", der)
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func writeKeys(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("writing key file: %v", err)
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+	return signed
+}
+
+func TestParseKeysPEM(t *testing.T) {
+	rsaPriv := rsaKey(t)
+	ecPriv, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPub, edPriv, _ := ed25519.GenerateKey(rand.Reader)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{CommonName: "auth"},
+		NotBefore: time.Now(),
+		NotAfter: time.Now().Add(time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, edPub, edPriv)
+	if err != nil {
+		t.Fatalf("creating certificate: %v", err)
+	}
+
+	var data []byte
+	data = append(data, publicPEM(t, &rsaPriv.PublicKey)...)
+	data = append(data, pemBlock(t, "RSA PUBLIC KEY", x509.MarshalPKCS1PublicKey(&rsaPriv.PublicKey))...)
+	data = append(data, publicPEM(t, &ecPriv.PublicKey)...)
+	data = append(data, pemBlock(t, "CERTIFICATE", certDER)...)
+
+	keys, err := parseKeys(data)
+	if err != nil {
+		t.Fatalf("parsing keys: %v", err)
+	}
+	if len(keys) != 4 {
+		t.Fatalf("got %d keys, want 4", len(keys))
+	}
+	if !rsaPriv.PublicKey.Equal(keys["0"]) || !rsaPriv.PublicKey.Equal(keys["1"]) {
+		t.Error("rsa keys do not match")
+	}
+	if !ecPriv.PublicKey.Equal(keys["2"]) {
+		t.Error("ec key does not match")
+	}
+	if !edPub.Equal(keys["3"]) {
+		t.Error("certificate key does not match")
+	}
+}
+
+func TestParseKeysJWKS(t *testing.T) {
+	rsaPriv := rsaKey(t)
+	ecPriv, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPub, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	data, _ := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa", "n": b64(rsaPriv.N.Bytes()), "e": b64(big.NewInt(int64(rsaPriv.E)).Bytes())},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": b64(ecPriv.X.Bytes()), "y": b64(ecPriv.Y.Bytes())},
+		{"kty": "OKP", "crv": "Ed25519", "x": b64(edPub)},
+		{"kty": "oct", "kid": "hmac", "k": b64([]byte("secret"))},
+	}})
+
+	keys, err := parseKeys(data)
+	if err != nil {
+		t.Fatalf("parsing keys: %v", err)
+	}
+	if !rsaPriv.PublicKey.Equal(keys["rsa"]) {
+		t.Error("rsa key does not match")
+	}
+	if !ecPriv.PublicKey.Equal(keys["ec"]) {
+		t.Error("ec key does not match")
+	}
+	// a key without kid is stored under its index
+	if !edPub.Equal(keys["2"]) {
+		t.Error("ed25519 key does not match")
+	}
+	if secret, ok := keys["hmac"].([]byte); !ok || string(secret) != "secret" {
+		t.Errorf("hmac key = %v, want secret", keys["hmac"])
+	}
+}
+
+func TestParseKeysSecret(t *testing.T) {
+	keys, err := parseKeys([]byte("  shared-secret\n"))
+	if err != nil {
+		t.Fatalf("parsing keys: %v", err)
+	}
+	if secret, ok := keys["0"].([]byte); !ok || string(secret) != "shared-secret" {
+		t.Errorf("secret = %v, want shared-secret", keys["0"])
+	}
+}
+
+func TestParseKeysInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"empty": "\n",
+		"broken json": `{"keys": [`,
+		"no keys in jwks": `{"keys": []}`,
+		"unknown key type": `{"keys": [{"kty": "XYZ"}]}`,
+		"unknown curve": `{"keys": [{"kty": "EC", "crv": "P-192", "x": "AQ", "y": "AQ"}]}`,
+		"broken pem": "-----BEGIN PUBLIC KEY-----\nAAAA\n-----END PUBLIC KEY-----\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parseKeys([]byte(data)); err == nil {
+				t.Error("parsing did not fail")
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	priv := rsaKey(t)
+	path := filepath.Join(t.TempDir(), "keys.pem")
+	writeKeys(t, path, publicPEM(t, &priv.PublicKey))
+	v, err := NewJWTVerifier(path, time.Hour)
+	if err != nil {
+		t.Fatalf("creating verifier: %v", err)
+	}
+
+	token := sign(t, jwt.SigningMethodRS256, priv, "", jwt.MapClaims{
+		"sub": "7",
+		"preferred_username": "alice",
+		"role": "manager",
+		"email": "alice@example.com",
+	})
+	user, complete, err := v.Verify(token)
+	if err != nil {
+		t.Fatalf("verifying: %v", err)
+	}
+	if !complete {
+		t.Error("claims with id and role are not complete")
+	}
+	want := models.User{Id: 7, Username: "alice", Role: models.RoleManager, Email: "alice@example.com"}
+	if *user != want {
+		t.Errorf("user = %+v, want %+v", *user, want)
+	}
+
+	other := rsaKey(t)
+	for name, token := range map[string]string{
+		"foreign key": sign(t, jwt.SigningMethodRS256, other, "", jwt.MapClaims{"sub": "7"}),
+		"expired": sign(t, jwt.SigningMethodRS256, priv, "", jwt.MapClaims{"sub": "7", "exp": time.Now().Add(-time.Minute).Unix()}),
+		"expired auth service claim": sign(t, jwt.SigningMethodRS256, priv, "", jwt.MapClaims{"Id": 7, "ExpiresAt": time.Now().Add(-time.Minute).Unix()}),
+		"unsigned": sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", jwt.MapClaims{"sub": "7"}),
+		// the public key must not pass as hmac secret
+		"hmac with public key": sign(t, jwt.SigningMethodHS256, x509.MarshalPKCS1PublicKey(&priv.PublicKey), "", jwt.MapClaims{"sub": "7"}),
+		"garbage": "not.a.token",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := v.Verify(token); !errors.Is(err, ErrorInvalidToken) {
+				t.Errorf("error = %v, want ErrorInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestVerifyIncompleteClaims(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	writeKeys(t, path, []byte("shared-secret"))
+	v, err := NewJWTVerifier(path, time.Hour)
+	if err != nil {
+		t.Fatalf("creating verifier: %v", err)
+	}
+
+	// the claims booking_auth puts into its tokens, there is no role
+	token := sign(t, jwt.SigningMethodHS256, []byte("shared-secret"), "", jwt.MapClaims{
+		"Id": 7,
+		"Username": "alice",
+		"ExpiresAt": time.Now().Add(time.Hour).Unix(),
+	})
+	user, complete, err := v.Verify(token)
+	if err != nil {
+		t.Fatalf("verifying: %v", err)
+	}
+	if complete {
+		t.Error("claims without role are complete")
+	}
+	if user.Id != 7 || user.Username != "alice" {
+		t.Errorf("user = %+v, want id 7 and username alice", *user)
+	}
+
+	forged := sign(t, jwt.SigningMethodHS256, []byte("other-secret"), "", jwt.MapClaims{"Id": 7})
+	if _, _, err := v.Verify(forged); !errors.Is(err, ErrorInvalidToken) {
+		t.Errorf("error = %v, want ErrorInvalidToken", err)
+	}
+}
+
+func TestVerifyKeyRotation(t *testing.T) {
+	oldKey, newKey := rsaKey(t), rsaKey(t)
+	jwksFile := func(keys map[string]*rsa.PrivateKey) []byte {
+		set := []map[string]string{}
+		for kid, key := range keys {
+			set = append(set, map[string]string{
+				"kty": "RSA",
+				"kid": kid,
+				"n": b64(key.N.Bytes()),
+				"e": b64(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		data, _ := json.Marshal(map[string]any{"keys": set})
+		return data
+	}
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeKeys(t, path, jwksFile(map[string]*rsa.PrivateKey{"old": oldKey}))
+	// the interval is long, only an unknown kid makes the verifier read the file again
+	v, err := NewJWTVerifier(path, time.Hour)
+	if err != nil {
+		t.Fatalf("creating verifier: %v", err)
+	}
+	claims := jwt.MapClaims{"sub": "7", "role": "client"}
+	if _, _, err := v.Verify(sign(t, jwt.SigningMethodRS256, oldKey, "old", claims)); err != nil {
+		t.Fatalf("verifying with the old key: %v", err)
+	}
+
+	writeKeys(t, path, jwksFile(map[string]*rsa.PrivateKey{"new": newKey}))
+	modTime := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("touching key file: %v", err)
+	}
+
+	if _, _, err := v.Verify(sign(t, jwt.SigningMethodRS256, newKey, "new", claims)); err != nil {
+		t.Fatalf("verifying with the new key: %v", err)
+	}
+	if _, _, err := v.Verify(sign(t, jwt.SigningMethodRS256, oldKey, "old", claims)); !errors.Is(err, ErrorInvalidToken) {
+		t.Errorf("error with the removed key = %v, want ErrorInvalidToken", err)
+	}
+
+	// a broken file keeps the keys loaded last
+	writeKeys(t, path, []byte(`{"keys": [`))
+	modTime = modTime.Add(time.Minute)
+	os.Chtimes(path, modTime, modTime)
+	if _, _, err := v.Verify(sign(t, jwt.SigningMethodRS256, newKey, "unknown", claims)); !errors.Is(err, ErrorInvalidToken) {
+		t.Errorf("error with an unknown kid = %v, want ErrorInvalidToken", err)
+	}
+	if _, _, err := v.Verify(sign(t, jwt.SigningMethodRS256, newKey, "new", claims)); err != nil {
+		t.Errorf("verifying after a broken reload: %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Bitummit/booking_api/internal/models"
+)
+
+func (s *HotelService) SearchAvailability(ctx context.Context, filter models.AvailabilityFilter) ([]models.HotelAvailability, error) {
+	nights, err := countNights(filter.From, filter.To)
+	if err != nil {
+		return nil, fmt.Errorf("searching availability: %w", err)
+	}
+	if filter.Guests <= 0 {
+		filter.Guests = 1
+	}
+
+	hotels, err := s.Storage.SearchAvailability(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("searching availability: %w", err)
+	}
+	for i := range hotels {
+		for j := range hotels[i].Categories {
+			category := &hotels[i].Categories[j]
+			category.TotalPrice = category.Category.Price * float64(nights)
+		}
+	}
+
+	return hotels, nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Bitummit/booking_api/internal/models"
+)
+
+func (s *HotelService) CreateBooking(ctx context.Context, booking models.Booking, hotelID, categoryID int64) (*models.Booking, error) {
+	user, ok := models.UserFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("creating booking: %w", ErrorUnauthorized)
+	}
+
+	nights, err := countNights(booking.EntryDate, booking.LeaveDate)
+	if err != nil {
+		return nil, fmt.Errorf("creating booking: %w", err)
+	}
+
+	var created *models.Booking
+	err = s.Storage.WithTx(ctx, func(ctx context.Context) error {
+		// deleted hotels can not be booked anymore
+		if _, err := s.Storage.GetHotel(ctx, hotelID); err != nil {
+			return fmt.Errorf("creating booking: %w", err)
+		}
+
+		category, err := s.Storage.GetRoomCategory(ctx, categoryID)
+		if err != nil {
+			return fmt.Errorf("creating booking: %w", err)
+		}
+		if category.HotelId != hotelID {
+			return fmt.Errorf("creating booking: %w", ErrorCategoryNotInHotel)
+		}
+		if booking.GuestsCount > category.Capacity {
+			return fmt.Errorf("creating booking: %w", ErrorCapacityExceeded)
+		}
+
+		booking.Price = category.Price * float64(nights)
+		booking.Status = models.BookingCreated
+		booking.UserId = user.Id
+		booking.HotelId = hotelID
+
+		created, err = s.Storage.CreateBooking(ctx, booking, categoryID)
+		if err != nil {
+			return fmt.Errorf("creating booking: %w", err)
+		}
+		return s.audit(ctx, models.AuditCreate, models.EntityBooking, created.Id, nil, created)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// countNights validates the stay dates and returns its length in nights.
+func countNights(entry, leave time.Time) (int64, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	if entry.Before(today) || !leave.After(entry) {
+		return 0, ErrorInvalidDates
+	}
+	return int64(leave.Sub(entry).Hours() / 24), nil
+}
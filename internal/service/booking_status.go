@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/Bitummit/booking_api/internal/models"
+)
+
+// TransitionError is returned when a booking can not move from its current status to the requested one.
+type TransitionError struct {
+	From string
+	To string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("booking can not move from %q to %q", e.From, e.To)
+}
+
+var (
+	bookingTransitions = map[string][]string{
+		models.BookingCreated: {models.BookingSubmitted, models.BookingCancelled},
+		models.BookingSubmitted: {models.BookingCheckedIn, models.BookingCancelled, models.BookingNoShow},
+		models.BookingCheckedIn: {models.BookingClosed},
+	}
+
+	guestStatuses = []string{models.BookingSubmitted, models.BookingCancelled}
+	managerStatuses = []string{models.BookingCheckedIn, models.BookingClosed, models.BookingCancelled, models.BookingNoShow}
+)
+
+// GuestChangeBookingStatus lets the guest who made the booking confirm or cancel it.
+func (s *HotelService) GuestChangeBookingStatus(ctx context.Context, bookingID int64, status string) (*models.Booking, error) {
+	user, ok := models.UserFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("changing booking status: %w", ErrorUnauthorized)
+	}
+
+	booking, err := s.Storage.GetBooking(ctx, bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("changing booking status: %w", err)
+	}
+	if booking.UserId != user.Id || !slices.Contains(guestStatuses, status) {
+		return nil, fmt.Errorf("changing booking status: %w", ErrorPermissionDenied)
+	}
+
+	if err := s.changeBookingStatus(ctx, booking, status); err != nil {
+		return nil, fmt.Errorf("changing booking status: %w", err)
+	}
+	return booking, nil
+}
+
+// ManagerChangeBookingStatus lets the hotel manager or an admin drive the stay itself.
+func (s *HotelService) ManagerChangeBookingStatus(ctx context.Context, hotelID, bookingID int64, status string) (*models.Booking, error) {
+	if err := s.CheckHotelManager(ctx, hotelID); err != nil {
+		return nil, fmt.Errorf("changing booking status: %w", err)
+	}
+
+	booking, err := s.Storage.GetBooking(ctx, bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("changing booking status: %w", err)
+	}
+	if booking.HotelId != hotelID {
+		return nil, fmt.Errorf("changing booking status: %w", ErrorBookingNotInHotel)
+	}
+	if !slices.Contains(managerStatuses, status) {
+		return nil, fmt.Errorf("changing booking status: %w", ErrorPermissionDenied)
+	}
+
+	if err := s.changeBookingStatus(ctx, booking, status); err != nil {
+		return nil, fmt.Errorf("changing booking status: %w", err)
+	}
+	return booking, nil
+}
+
+func (s *HotelService) changeBookingStatus(ctx context.Context, booking *models.Booking, status string) error {
+	if !slices.Contains(bookingTransitions[booking.Status], status) {
+		return &TransitionError{From: booking.Status, To: status}
+	}
+
+	err := s.Storage.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.Storage.UpdateBookingStatus(ctx, booking.Id, booking.Status, status); err != nil {
+			return err
+		}
+		before := map[string]string{"status": booking.Status}
+		return s.audit(ctx, models.AuditChangeStatus, models.EntityBooking, booking.Id, before, map[string]string{"status": status})
+	})
+	if err != nil {
+		return err
+	}
+	booking.Status = status
+	return nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Bitummit/booking_api/internal/models"
+)
+
+func (s *HotelService) CreateRoomCategory(ctx context.Context, category models.RoomCategory) (int64, error) {
+	if err := s.CheckHotelManager(ctx, category.HotelId); err != nil {
+		return 0, fmt.Errorf("creating room category: %w", err)
+	}
+
+	var id int64
+	err := s.Storage.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = s.Storage.CreateRoomCategory(ctx, category)
+		if err != nil {
+			return fmt.Errorf("creating room category: %w", err)
+		}
+		category.Id = id
+		return s.audit(ctx, models.AuditCreate, models.EntityRoomCategory, id, nil, category)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *HotelService) ListRoomCategories(ctx context.Context, hotelID int64) ([]models.RoomCategory, error) {
+	// deleted hotels keep their categories for the booking history, but they are not listed
+	if _, err := s.Storage.GetHotelManager(ctx, hotelID); err != nil {
+		return nil, fmt.Errorf("getting room categories: %w", err)
+	}
+	categories, err := s.Storage.ListRoomCategories(ctx, hotelID)
+	if err != nil {
+		return nil, fmt.Errorf("getting room categories: %w", err)
+	}
+	return categories, nil
+}
+
+func (s *HotelService) UpdateRoomCategory(ctx context.Context, category models.RoomCategory) error {
+	if err := s.CheckHotelManager(ctx, category.HotelId); err != nil {
+		return fmt.Errorf("updating room category: %w", err)
+	}
+
+	return s.Storage.WithTx(ctx, func(ctx context.Context) error {
+		before, err := s.Storage.GetRoomCategory(ctx, category.Id)
+		if err != nil {
+			return fmt.Errorf("updating room category: %w", err)
+		}
+		if err := s.Storage.UpdateRoomCategory(ctx, category); err != nil {
+			return fmt.Errorf("updating room category: %w", err)
+		}
+		after, err := s.Storage.GetRoomCategory(ctx, category.Id)
+		if err != nil {
+			return fmt.Errorf("updating room category: %w", err)
+		}
+		return s.audit(ctx, models.AuditUpdate, models.EntityRoomCategory, category.Id, before, after)
+	})
+}
+
+func (s *HotelService) DeleteRoomCategory(ctx context.Context, hotelID, id int64) error {
+	if err := s.CheckHotelManager(ctx, hotelID); err != nil {
+		return fmt.Errorf("deleting room category: %w", err)
+	}
+
+	return s.Storage.WithTx(ctx, func(ctx context.Context) error {
+		before, err := s.Storage.GetRoomCategory(ctx, id)
+		if err != nil {
+			return fmt.Errorf("deleting room category: %w", err)
+		}
+		if err := s.Storage.DeleteRoomCategory(ctx, hotelID, id); err != nil {
+			return fmt.Errorf("deleting room category: %w", err)
+		}
+		return s.audit(ctx, models.AuditDelete, models.EntityRoomCategory, id, before, nil)
+	})
+}
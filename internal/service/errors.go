@@ -0,0 +1,12 @@
+package service
+
+import "errors"
+
+var ErrorUnauthorized = errors.New("unauthorized")
+var ErrorPermissionDenied = errors.New("permission denied")
+var ErrorCategoryNotInHotel = errors.New("category does not belong to hotel")
+var ErrorInvalidDates = errors.New("invalid stay dates")
+var ErrorCapacityExceeded = errors.New("too many guests for room category")
+var ErrorBookingNotInHotel = errors.New("booking does not belong to hotel")
+var ErrorInvalidCursor = errors.New("invalid cursor")
+var ErrorInvalidRole = errors.New("invalid role")
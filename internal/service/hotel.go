@@ -10,40 +10,94 @@ import (
 type (
 	HotelService struct {
 		Storage HotelStorage
+		Roles RoleUpdater
+		Auditor Auditor
 	}
 
 	HotelStorage interface {
 		CreateTag(ctx context.Context, tag models.Tag) (int64, error)
 		ListTags(ctx context.Context) ([]models.Tag, error)
+		GetTag(ctx context.Context, id int64) (*models.Tag, error)
 		DeleteTag(ctx context.Context, id int64) error
 		CreateCity(ctx context.Context, city models.City) (int64, error)
 		ListCities(ctx context.Context) ([]models.City, error)
+		GetCity(ctx context.Context, id int64) (*models.City, error)
 		DeleteCity(ctx context.Context, id int64) error
 		CreateHotel(ctx context.Context, hotel models.Hotel, cityName string, tags []string) (int64, error)
-		UpdateUserRole(ctx context.Context, username string) error
 		GetHotelsByManager(ctx context.Context, user_id int64) ([]*models.Hotel, error)
 		GetAllHotes(ctx context.Context) ([]*models.Hotel, error)
+		GetHotel(ctx context.Context, id int64) (*models.Hotel, error)
+		UpdateHotel(ctx context.Context, id int64, update models.HotelUpdate) error
+		DeleteHotel(ctx context.Context, id int64) error
+		TransferHotel(ctx context.Context, transfer models.HotelTransfer) (*models.HotelTransfer, error)
+		ListHotelTransfers(ctx context.Context, hotelID int64) ([]models.HotelTransfer, error)
+		GetHotelManager(ctx context.Context, hotelID int64) (int64, error)
+		CreateRoomCategory(ctx context.Context, category models.RoomCategory) (int64, error)
+		ListRoomCategories(ctx context.Context, hotelID int64) ([]models.RoomCategory, error)
+		GetRoomCategory(ctx context.Context, id int64) (*models.RoomCategory, error)
+		UpdateRoomCategory(ctx context.Context, category models.RoomCategory) error
+		DeleteRoomCategory(ctx context.Context, hotelID, id int64) error
+		CreateRoom(ctx context.Context, hotelID int64, room models.Room) (int64, error)
+		ListRooms(ctx context.Context, categoryID int64) ([]models.Room, error)
+		GetRoom(ctx context.Context, id int64) (*models.Room, error)
+		DeleteRoom(ctx context.Context, categoryID, id int64) (bool, error)
+		CreateBooking(ctx context.Context, booking models.Booking, categoryID int64) (*models.Booking, error)
+		GetBooking(ctx context.Context, id int64) (*models.Booking, error)
+		UpdateBookingStatus(ctx context.Context, id int64, from, to string) error
+		SearchHotels(ctx context.Context, filter models.HotelFilter) ([]*models.Hotel, *models.HotelCursor, error)
+		SearchAvailability(ctx context.Context, filter models.AvailabilityFilter) ([]models.HotelAvailability, error)
+		ListUsers(ctx context.Context, filter models.UserFilter) ([]models.User, error)
+		GetUser(ctx context.Context, id int64) (*models.User, error)
+		GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+		IsUserBlocked(ctx context.Context, id int64) (bool, error)
+		SetUserBlocked(ctx context.Context, id int64, blocked bool) error
+		UpdateUserProfile(ctx context.Context, user models.User) error
+		RecordAudit(ctx context.Context, entry models.AuditEntry) error
+		ListAudit(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+		// WithTx runs fn in one transaction, storage calls made with the ctx passed to fn join it.
+		// fn runs again after a deadlock or a serialization failure, a nested call rolls back
+		// only its own writes on error.
+		WithTx(ctx context.Context, fn func(ctx context.Context) error) error
 	}
 )
 
 func New(storage HotelStorage) *HotelService {
 	return &HotelService{
 		Storage: storage,
+		Auditor: storage,
 	}
 }
 
 func (s *HotelService) CreateTag(ctx context.Context, tag models.Tag) (int64, error) {
-	id, err := s.Storage.CreateTag(ctx, tag)
+	var id int64
+	err := s.Storage.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = s.Storage.CreateTag(ctx, tag)
+		if err != nil {
+			return fmt.Errorf("creating new tag: %w", err)
+		}
+		tag.Id = id
+		return s.audit(ctx, models.AuditCreate, models.EntityTag, id, nil, tag)
+	})
 	if err != nil {
-		return 0, fmt.Errorf("creating new tag: %w", err)
+		return 0, err
 	}
 	return id, nil
 }
 
 func (s *HotelService) CreateCity(ctx context.Context, city models.City) (int64, error) {
-	id, err := s.Storage.CreateCity(ctx, city)
+	var id int64
+	err := s.Storage.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = s.Storage.CreateCity(ctx, city)
+		if err != nil {
+			return fmt.Errorf("creating new city: %w", err)
+		}
+		city.Id = id
+		return s.audit(ctx, models.AuditCreate, models.EntityCity, id, nil, city)
+	})
 	if err != nil {
-		return 0, fmt.Errorf("creating new city: %w", err)
+		return 0, err
 	}
 	return id, nil
 }
@@ -65,44 +119,142 @@ func (s *HotelService) ListCities(ctx context.Context) ([]models.City, error) {
 }
 
 func (s *HotelService) DeleteTag(ctx context.Context, id int64) error {
-	err := s.Storage.DeleteTag(ctx, id)
+	return s.Storage.WithTx(ctx, func(ctx context.Context) error {
+		before, err := s.Storage.GetTag(ctx, id)
+		if err != nil {
+			return fmt.Errorf("deleting tag: %w", err)
+		}
+		if err := s.Storage.DeleteTag(ctx, id); err != nil {
+			return fmt.Errorf("deleting tag: %w", err)
+		}
+		return s.audit(ctx, models.AuditDelete, models.EntityTag, id, before, nil)
+	})
+}
+
+func (s *HotelService) DeleteCity(ctx context.Context, id int64) error {
+	return s.Storage.WithTx(ctx, func(ctx context.Context) error {
+		before, err := s.Storage.GetCity(ctx, id)
+		if err != nil {
+			return fmt.Errorf("deleting city: %w", err)
+		}
+		if err := s.Storage.DeleteCity(ctx, id); err != nil {
+			return fmt.Errorf("deleting city: %w", err)
+		}
+		return s.audit(ctx, models.AuditDelete, models.EntityCity, id, before, nil)
+	})
+}
+
+func (s *HotelService) CreateHotel(ctx context.Context, hotel models.Hotel, cityName string, tags []string) (int64, error) {
+	user, ok := models.UserFromContext(ctx)
+	if !ok {
+		return 0, fmt.Errorf("creating hotel: %w", ErrorUnauthorized)
+	}
+	hotel.ManagerId = user.Id
+
+	// the audit entry is written in the same transaction, so a failed one undoes the hotel
+	var hotelID int64
+	err := s.Storage.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		hotelID, err = s.Storage.CreateHotel(ctx, hotel, cityName, tags)
+		if err != nil {
+			return fmt.Errorf("creating hotel: %w", err)
+		}
+		created, err := s.Storage.GetHotel(ctx, hotelID)
+		if err != nil {
+			return fmt.Errorf("creating hotel: %w", err)
+		}
+		return s.audit(ctx, models.AuditCreate, models.EntityHotel, hotelID, nil, created)
+	})
 	if err != nil {
-		return fmt.Errorf("deleting tag: %w", err)
+		return 0, err
 	}
-	return nil
+	return hotelID, nil
 }
 
-func (s *HotelService) DeleteCity(ctx context.Context, id int64) error {
-	err := s.Storage.DeleteCity(ctx, id)
+// TransferHotel hands the hotel over to another manager on behalf of the calling admin.
+func (s *HotelService) TransferHotel(ctx context.Context, hotelID, managerID int64) (*models.HotelTransfer, error) {
+	user, ok := models.UserFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("transferring hotel: %w", ErrorUnauthorized)
+	}
+
+	transfer := models.HotelTransfer{
+		HotelId: hotelID,
+		ToManagerId: managerID,
+		ActorId: user.Id,
+	}
+	var created *models.HotelTransfer
+	err := s.Storage.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		created, err = s.Storage.TransferHotel(ctx, transfer)
+		if err != nil {
+			return fmt.Errorf("transferring hotel: %w", err)
+		}
+		before := map[string]int64{"manager_id": created.FromManagerId}
+		after := map[string]int64{"manager_id": created.ToManagerId}
+		return s.audit(ctx, models.AuditTransfer, models.EntityHotel, hotelID, before, after)
+	})
 	if err != nil {
-		return fmt.Errorf("deleting city: %w", err)
+		return nil, err
 	}
-	return nil
+	return created, nil
 }
 
-func (s *HotelService) CreateHotel(ctx context.Context, hotel models.Hotel, cityName string, tags []string) (int64, error) {
-	// get city obj
-	// city, err := s.Storage.getCity(ctx, cityName)
-	// if err != nil {
-	// 	return 0, fmt.Errorf("creating hotel: %w", err)
-	// }
-	// get tag objects
-	//create city
-	// hotel.CityId = city.Id
-	hotelID, err := s.Storage.CreateHotel(ctx, hotel, cityName, tags)
+func (s *HotelService) ListHotelTransfers(ctx context.Context, hotelID int64) ([]models.HotelTransfer, error) {
+	transfers, err := s.Storage.ListHotelTransfers(ctx, hotelID)
 	if err != nil {
-		return hotelID, fmt.Errorf("creating hotel: %w", err)
+		return nil, fmt.Errorf("getting hotel transfers: %w", err)
 	}
-	return hotelID, nil
+	return transfers, nil
+}
+
+func (s *HotelService) UpdateHotel(ctx context.Context, id int64, update models.HotelUpdate) error {
+	if err := s.CheckHotelManager(ctx, id); err != nil {
+		return fmt.Errorf("updating hotel: %w", err)
+	}
+
+	return s.Storage.WithTx(ctx, func(ctx context.Context) error {
+		before, err := s.Storage.GetHotel(ctx, id)
+		if err != nil {
+			return fmt.Errorf("updating hotel: %w", err)
+		}
+		if err := s.Storage.UpdateHotel(ctx, id, update); err != nil {
+			return fmt.Errorf("updating hotel: %w", err)
+		}
+		after, err := s.Storage.GetHotel(ctx, id)
+		if err != nil {
+			return fmt.Errorf("updating hotel: %w", err)
+		}
+		return s.audit(ctx, models.AuditUpdate, models.EntityHotel, id, before, after)
+	})
+}
+
+func (s *HotelService) DeleteHotel(ctx context.Context, id int64) error {
+	if err := s.CheckHotelManager(ctx, id); err != nil {
+		return fmt.Errorf("deleting hotel: %w", err)
+	}
+
+	return s.Storage.WithTx(ctx, func(ctx context.Context) error {
+		before, err := s.Storage.GetHotel(ctx, id)
+		if err != nil {
+			return fmt.Errorf("deleting hotel: %w", err)
+		}
+		if err := s.Storage.DeleteHotel(ctx, id); err != nil {
+			return fmt.Errorf("deleting hotel: %w", err)
+		}
+		return s.audit(ctx, models.AuditDelete, models.EntityHotel, id, before, nil)
+	})
 }
 
 func (s *HotelService) ListHotels(ctx context.Context,) ([]*models.Hotel, error) {
 	var hotels []*models.Hotel
 	var err error
 	
-	user := ctx.Value("user").(*models.User)
-	fmt.Printf("%v\n", user)
-	if user.Role == "manager"{
+	user, ok := models.UserFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("getting hotel list: %w", ErrorUnauthorized)
+	}
+	if user.Role == models.RoleManager {
 		hotels, err = s.Storage.GetHotelsByManager(ctx, user.Id)
 	} else {
 		hotels, err = s.Storage.GetAllHotes(ctx)
@@ -113,3 +265,57 @@ func (s *HotelService) ListHotels(ctx context.Context,) ([]*models.Hotel, error)
 
 	return hotels, nil	
 }
+
+
+// GetHotel returns the hotel with its room categories. Availability of the categories is
+// only looked up when a stay is given.
+func (s *HotelService) GetHotel(ctx context.Context, id int64, stay *models.AvailabilityFilter) (*models.Hotel, []models.CategoryAvailability, error) {
+	hotel, err := s.Storage.GetHotel(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting hotel: %w", err)
+	}
+
+	hotel.Categories, err = s.Storage.ListRoomCategories(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting hotel: %w", err)
+	}
+
+	if stay == nil {
+		return hotel, nil, nil
+	}
+	// the caller's filter is left as it is
+	filter := *stay
+	filter.City = ""
+	filter.HotelId = id
+	hotels, err := s.SearchAvailability(ctx, filter)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting hotel: %w", err)
+	}
+	availability := []models.CategoryAvailability{}
+	if len(hotels) > 0 {
+		availability = hotels[0].Categories
+	}
+
+	return hotel, availability, nil
+}
+
+// CheckHotelManager allows the call only for admins and the manager who owns the hotel.
+func (s *HotelService) CheckHotelManager(ctx context.Context, hotelID int64) error {
+	user, ok := models.UserFromContext(ctx)
+	if !ok {
+		return ErrorUnauthorized
+	}
+
+	managerID, err := s.Storage.GetHotelManager(ctx, hotelID)
+	if err != nil {
+		return fmt.Errorf("getting hotel manager: %w", err)
+	}
+	if user.Role == models.RoleAdmin {
+		return nil
+	}
+	if user.Role != models.RoleManager || managerID != user.Id {
+		return ErrorPermissionDenied
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Bitummit/booking_api/internal/models"
+)
+
+func TestGetHotelKeepsStay(t *testing.T) {
+	s, storage, ctx := newService(t)
+	if _, err := storage.CreateCity(ctx, models.City{Name: "Almaty"}); err != nil {
+		t.Fatalf("creating city: %v", err)
+	}
+	hotelID, err := storage.CreateHotel(ctx, models.Hotel{Name: "Lake", ManagerId: 1}, "Almaty", nil)
+	if err != nil {
+		t.Fatalf("creating hotel: %v", err)
+	}
+	categoryID, err := storage.CreateRoomCategory(ctx, models.RoomCategory{HotelId: hotelID, Name: "Standard", Price: 100, Capacity: 2, Size: 20})
+	if err != nil {
+		t.Fatalf("creating category: %v", err)
+	}
+	if _, err := storage.CreateRoom(ctx, hotelID, models.Room{Number: "1", CategoryId: categoryID}); err != nil {
+		t.Fatalf("creating room: %v", err)
+	}
+
+	// the city of the stay is ignored, the hotel decides it
+	from := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 7)
+	stay := models.AvailabilityFilter{City: "Astana", From: from, To: from.AddDate(0, 0, 3), Guests: 2}
+	want := stay
+	_, availability, err := s.GetHotel(ctx, hotelID, &stay)
+	if err != nil {
+		t.Fatalf("getting hotel: %v", err)
+	}
+	if len(availability) != 1 || availability[0].Category.Id != categoryID || availability[0].FreeRooms != 1 {
+		t.Fatalf("availability = %+v", availability)
+	}
+	if stay != want {
+		t.Fatalf("stay changed to %+v, want %+v", stay, want)
+	}
+}
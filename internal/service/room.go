@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Bitummit/booking_api/internal/models"
+)
+
+func (s *HotelService) CreateRoom(ctx context.Context, hotelID int64, room models.Room) (int64, error) {
+	if err := s.checkHotelCategory(ctx, hotelID, room.CategoryId); err != nil {
+		return 0, fmt.Errorf("creating room: %w", err)
+	}
+
+	var id int64
+	err := s.Storage.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = s.Storage.CreateRoom(ctx, hotelID, room)
+		if err != nil {
+			return fmt.Errorf("creating room: %w", err)
+		}
+		after, err := s.Storage.GetRoom(ctx, id)
+		if err != nil {
+			return fmt.Errorf("creating room: %w", err)
+		}
+		return s.audit(ctx, models.AuditCreate, models.EntityRoom, id, nil, after)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *HotelService) ListRooms(ctx context.Context, hotelID, categoryID int64) ([]models.Room, error) {
+	if err := s.checkHotelCategory(ctx, hotelID, categoryID); err != nil {
+		return nil, fmt.Errorf("getting rooms: %w", err)
+	}
+
+	rooms, err := s.Storage.ListRooms(ctx, categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("getting rooms: %w", err)
+	}
+	return rooms, nil
+}
+
+// DeleteRoom reports whether the room was retired instead of being deleted.
+func (s *HotelService) DeleteRoom(ctx context.Context, hotelID, categoryID, id int64) (bool, error) {
+	if err := s.checkHotelCategory(ctx, hotelID, categoryID); err != nil {
+		return false, fmt.Errorf("deleting room: %w", err)
+	}
+
+	var retired bool
+	err := s.Storage.WithTx(ctx, func(ctx context.Context) error {
+		before, err := s.Storage.GetRoom(ctx, id)
+		if err != nil {
+			return fmt.Errorf("deleting room: %w", err)
+		}
+		retired, err = s.Storage.DeleteRoom(ctx, categoryID, id)
+		if err != nil {
+			return fmt.Errorf("deleting room: %w", err)
+		}
+		var after any
+		if retired {
+			// retired rooms stay in the database, only inactive
+			room, err := s.Storage.GetRoom(ctx, id)
+			if err != nil {
+				return fmt.Errorf("deleting room: %w", err)
+			}
+			after = room
+		}
+		return s.audit(ctx, models.AuditDelete, models.EntityRoom, id, before, after)
+	})
+	if err != nil {
+		return false, err
+	}
+	return retired, nil
+}
+
+// checkHotelCategory checks hotel permissions and that the category belongs to the hotel.
+func (s *HotelService) checkHotelCategory(ctx context.Context, hotelID, categoryID int64) error {
+	if err := s.CheckHotelManager(ctx, hotelID); err != nil {
+		return err
+	}
+
+	category, err := s.Storage.GetRoomCategory(ctx, categoryID)
+	if err != nil {
+		return fmt.Errorf("getting room category: %w", err)
+	}
+	if category.HotelId != hotelID {
+		return fmt.Errorf("getting room category: %w", ErrorCategoryNotInHotel)
+	}
+
+	return nil
+}
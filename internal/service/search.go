@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Bitummit/booking_api/internal/models"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit = 100
+)
+
+// SearchHotels returns one page of hotels and an opaque cursor for the next one.
+func (s *HotelService) SearchHotels(ctx context.Context, filter models.HotelFilter, cursor string) ([]*models.Hotel, string, error) {
+	if filter.Sort == "" {
+		filter.Sort = "id"
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultSearchLimit
+	}
+	filter.Limit = min(filter.Limit, MaxSearchLimit)
+
+	if cursor != "" {
+		decoded, err := decodeCursor(cursor)
+		if err != nil || decoded.Sort != filter.Sort {
+			return nil, "", fmt.Errorf("searching hotels: %w", ErrorInvalidCursor)
+		}
+		filter.Cursor = decoded
+	}
+
+	hotels, next, err := s.Storage.SearchHotels(ctx, filter)
+	if err != nil {
+		return nil, "", fmt.Errorf("searching hotels: %w", err)
+	}
+	if hotels == nil {
+		hotels = []*models.Hotel{}
+	}
+	if next == nil {
+		return hotels, "", nil
+	}
+
+	nextCursor, err := encodeCursor(next)
+	if err != nil {
+		return nil, "", fmt.Errorf("searching hotels: %w", err)
+	}
+	return hotels, nextCursor, nil
+}
+
+func encodeCursor(cursor *models.HotelCursor) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", fmt.Errorf("encoding cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(cursor string) (*models.HotelCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("decoding cursor: %w", err)
+	}
+
+	var decoded models.HotelCursor
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("decoding cursor: %w", err)
+	}
+	return &decoded, nil
+}
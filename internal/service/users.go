@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Bitummit/booking_api/internal/models"
+)
+
+const (
+	DefaultUsersLimit = 50
+	MaxUsersLimit = 200
+)
+
+// RoleUpdater changes roles in the auth service, which owns them.
+type RoleUpdater interface {
+	UpdateUserRole(ctx context.Context, role models.Role, username string) error
+}
+
+func (s *HotelService) ListUsers(ctx context.Context, filter models.UserFilter) ([]models.User, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultUsersLimit
+	}
+	filter.Limit = min(filter.Limit, MaxUsersLimit)
+	filter.Offset = max(filter.Offset, 0)
+
+	users, err := s.Storage.ListUsers(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("listing users: %w", err)
+	}
+	return users, nil
+}
+
+func (s *HotelService) GetUser(ctx context.Context, id int64) (*models.User, error) {
+	user, err := s.Storage.GetUser(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("getting user: %w", err)
+	}
+	return user, nil
+}
+
+func (s *HotelService) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	user, err := s.Storage.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("getting user: %w", err)
+	}
+	return user, nil
+}
+
+// IsUserBlocked reports whether the user was blocked by an admin.
+func (s *HotelService) IsUserBlocked(ctx context.Context, id int64) (bool, error) {
+	blocked, err := s.Storage.IsUserBlocked(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("checking user: %w", err)
+	}
+	return blocked, nil
+}
+
+// ChangeUserRole sets the role in the auth service and records the change on behalf of the
+// calling admin. The auth service is called last in the transaction, so a failed call leaves
+// no audit entry. Setting a role twice does no harm when the transaction is retried.
+func (s *HotelService) ChangeUserRole(ctx context.Context, id int64, role models.Role) (*models.User, error) {
+	actor, ok := models.UserFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("changing role: %w", ErrorUnauthorized)
+	}
+	if !role.Valid() {
+		return nil, fmt.Errorf("changing role: %w", ErrorInvalidRole)
+	}
+	if s.Roles == nil {
+		return nil, fmt.Errorf("changing role: no role updater configured")
+	}
+
+	var user *models.User
+	err := s.Storage.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.Storage.GetUser(ctx, id)
+		if err != nil {
+			return fmt.Errorf("changing role: %w", err)
+		}
+		if user.Role == role {
+			return nil
+		}
+		if user.Id == actor.Id {
+			// an admin locking themselves out has to be done by another admin
+			return fmt.Errorf("changing own role: %w", ErrorPermissionDenied)
+		}
+
+		before := map[string]models.Role{"role": user.Role}
+		if err := s.audit(ctx, models.AuditChangeRole, models.EntityUser, user.Id, before, map[string]models.Role{"role": role}); err != nil {
+			return err
+		}
+		if err := s.Roles.UpdateUserRole(ctx, role, user.Username); err != nil {
+			return fmt.Errorf("changing role: %w", err)
+		}
+		user.Role = role
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *HotelService) SetUserBlocked(ctx context.Context, id int64, blocked bool) (*models.User, error) {
+	actor, ok := models.UserFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("blocking user: %w", ErrorUnauthorized)
+	}
+	if id == actor.Id {
+		return nil, fmt.Errorf("blocking self: %w", ErrorPermissionDenied)
+	}
+
+	var user *models.User
+	err := s.Storage.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.Storage.GetUser(ctx, id)
+		if err != nil {
+			return fmt.Errorf("blocking user: %w", err)
+		}
+		if user.Blocked == blocked {
+			return nil
+		}
+
+		if err := s.Storage.SetUserBlocked(ctx, id, blocked); err != nil {
+			return fmt.Errorf("blocking user: %w", err)
+		}
+		action := models.AuditUnblock
+		if blocked {
+			action = models.AuditBlock
+		}
+		user.Blocked = blocked
+		return s.audit(ctx, action, models.EntityUser, id, map[string]bool{"blocked": !blocked}, map[string]bool{"blocked": blocked})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// UpdateProfile changes the profile of the calling user. Users are rows of the auth service
+// table, it serves the new values from there.
+func (s *HotelService) UpdateProfile(ctx context.Context, update models.ProfileUpdate) (*models.User, error) {
+	actor, ok := models.UserFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("updating profile: %w", ErrorUnauthorized)
+	}
+
+	var user *models.User
+	err := s.Storage.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.Storage.GetUser(ctx, actor.Id)
+		if err != nil {
+			return fmt.Errorf("updating profile: %w", err)
+		}
+		before := profileState(user)
+		if update.FirstName != nil {
+			user.FirstName = *update.FirstName
+		}
+		if update.LastName != nil {
+			user.LastName = *update.LastName
+		}
+		if update.Email != nil {
+			user.Email = *update.Email
+		}
+		if update.Birthday != nil {
+			user.Birthday = *update.Birthday
+		}
+
+		if err := s.Storage.UpdateUserProfile(ctx, *user); err != nil {
+			return fmt.Errorf("updating profile: %w", err)
+		}
+		return s.audit(ctx, models.AuditUpdate, models.EntityUser, user.Id, before, profileState(user))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// profileState is the audited part of the user, models.User also carries the password.
+func profileState(user *models.User) map[string]string {
+	state := map[string]string{
+		"first_name": user.FirstName,
+		"last_name": user.LastName,
+		"email": user.Email,
+	}
+	if !user.Birthday.IsZero() {
+		state["birthday"] = user.Birthday.Format(time.DateOnly)
+	}
+	return state
+}
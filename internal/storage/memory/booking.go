@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/Bitummit/booking_api/internal/storage/postgresql"
+)
+
+// CreateBooking books the first free room of the category.
+func (s *Storage) CreateBooking(ctx context.Context, booking models.Booking, categoryID int64) (*models.Booking, error) {
+	defer s.lock(ctx)()
+
+	for _, room := range s.rooms.all() {
+		if room.CategoryId != categoryID || !room.Active || !s.roomFree(room.Id, booking.EntryDate, booking.LeaveDate) {
+			continue
+		}
+		booking.RoomId = room.Id
+		booking.Id = s.bookings.insert(func(id int64) models.Booking {
+			stored := booking
+			stored.Id = id
+			// the hotel is taken from the room category when the booking is read
+			stored.HotelId = 0
+			return stored
+		})
+		return &booking, nil
+	}
+	return nil, fmt.Errorf("database error: %w", postgresql.ErrorNoFreeRoom)
+}
+
+func (s *Storage) GetBooking(ctx context.Context, id int64) (*models.Booking, error) {
+	defer s.rlock(ctx)()
+
+	booking, ok := s.bookings.get(id)
+	if !ok {
+		return nil, fmt.Errorf("database error: %w", postgresql.ErrorNotExists)
+	}
+	room, _ := s.rooms.get(booking.RoomId)
+	category, _ := s.categories.get(room.CategoryId)
+	booking.HotelId = category.HotelId
+	return &booking, nil
+}
+
+// UpdateBookingStatus moves the booking only if it is still in the from status.
+func (s *Storage) UpdateBookingStatus(ctx context.Context, id int64, from, to string) error {
+	defer s.lock(ctx)()
+
+	booking, ok := s.bookings.get(id)
+	if !ok || booking.Status != from {
+		return fmt.Errorf("updating: %w", postgresql.ErrorStatusChanged)
+	}
+	booking.Status = to
+	s.bookings.set(id, booking)
+	return nil
+}
+
+// SearchAvailability returns room categories that have at least one room free for the whole stay.
+func (s *Storage) SearchAvailability(ctx context.Context, filter models.AvailabilityFilter) ([]models.HotelAvailability, error) {
+	defer s.rlock(ctx)()
+
+	hotels := []models.HotelAvailability{}
+	for _, row := range s.hotels.all() {
+		city, _ := s.cities.get(row.CityId)
+		if !row.Active {
+			continue
+		}
+		if filter.HotelId != 0 {
+			if row.Id != filter.HotelId {
+				continue
+			}
+		} else if filter.City != "" && city.Name != filter.City {
+			continue
+		}
+
+		var available []models.CategoryAvailability
+		for _, category := range s.categories.all() {
+			if category.HotelId != row.Id || category.Capacity < filter.Guests {
+				continue
+			}
+			var free int64
+			for _, room := range s.rooms.all() {
+				if room.CategoryId == category.Id && room.Active && s.roomFree(room.Id, filter.From, filter.To) {
+					free++
+				}
+			}
+			if free > 0 {
+				available = append(available, models.CategoryAvailability{Category: category, FreeRooms: free})
+			}
+		}
+		if len(available) == 0 {
+			continue
+		}
+		slices.SortStableFunc(available, func(a, b models.CategoryAvailability) int {
+			return cmp.Compare(a.Category.Price, b.Category.Price)
+		})
+
+		hotel := models.Hotel{
+			Id: row.Id,
+			Name: row.Name,
+			Desc: row.Desc,
+			City: models.City{Name: city.Name},
+			BaseModel: models.BaseModel{
+				CreatedAt: row.CreatedAt,
+				Active: row.Active,
+			},
+		}
+		hotels = append(hotels, models.HotelAvailability{Hotel: hotel, Categories: available})
+	}
+	return hotels, nil
+}
+
+// roomFree mirrors the booking_no_overlap constraint: stays are half open date ranges and
+// cancelled or no-show bookings do not hold the room.
+func (s *Storage) roomFree(roomID int64, from, to time.Time) bool {
+	_, taken := s.bookings.find(func(b models.Booking) bool {
+		return b.RoomId == roomID &&
+			b.Status != models.BookingCancelled && b.Status != models.BookingNoShow &&
+			b.EntryDate.Before(to) && from.Before(b.LeaveDate)
+	})
+	return !taken
+}
@@ -0,0 +1,408 @@
+// Package memory keeps the whole storage in process memory. It follows the semantics of the
+// postgresql storage, errors included, and is meant for demos and fast tests.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/Bitummit/booking_api/internal/storage/postgresql"
+)
+
+// Storage is safe for concurrent use, every method runs under one lock and so is atomic.
+// WithTx makes several calls atomic together.
+type Storage struct {
+	mu sync.RWMutex
+	now func() time.Time
+
+	tables
+}
+
+// tables is the whole data set, WithTx copies it to roll back.
+type tables struct {
+	tags table[models.Tag]
+	cities table[models.City]
+	hotels table[hotelRow]
+	tagHotels table[tagHotelRow]
+	categories table[models.RoomCategory]
+	rooms table[models.Room]
+	bookings table[models.Booking]
+	transfers table[models.HotelTransfer]
+	users table[models.User]
+	audit table[models.AuditEntry]
+}
+
+func (t tables) clone() tables {
+	return tables{
+		tags: t.tags.clone(),
+		cities: t.cities.clone(),
+		hotels: t.hotels.clone(),
+		tagHotels: t.tagHotels.clone(),
+		categories: t.categories.clone(),
+		rooms: t.rooms.clone(),
+		bookings: t.bookings.clone(),
+		transfers: t.transfers.clone(),
+		users: t.users.clone(),
+		audit: t.audit.clone(),
+	}
+}
+
+type hotelRow struct {
+	Id int64
+	Name string
+	Desc string
+	CityId int64
+	ManagerId int64
+	Active bool
+	CreatedAt time.Time
+}
+
+type tagHotelRow struct {
+	Id int64
+	HotelId int64
+	TagId int64
+}
+
+func New() *Storage {
+	return &Storage{
+		now: time.Now,
+	}
+}
+
+// table is a SERIAL keyed set of rows.
+type table[T any] struct {
+	seq int64
+	rows map[int64]T
+}
+
+func (t *table[T]) insert(row func(id int64) T) int64 {
+	if t.rows == nil {
+		t.rows = make(map[int64]T)
+	}
+	t.seq++
+	t.rows[t.seq] = row(t.seq)
+	return t.seq
+}
+
+func (t table[T]) clone() table[T] {
+	return table[T]{seq: t.seq, rows: maps.Clone(t.rows)}
+}
+
+func (t *table[T]) get(id int64) (T, bool) {
+	row, ok := t.rows[id]
+	return row, ok
+}
+
+func (t *table[T]) set(id int64, row T) {
+	t.rows[id] = row
+}
+
+func (t *table[T]) delete(id int64) {
+	delete(t.rows, id)
+}
+
+// all returns the rows ordered by id.
+func (t *table[T]) all() []T {
+	rows := make([]T, 0, len(t.rows))
+	for _, id := range slices.Sorted(maps.Keys(t.rows)) {
+		rows = append(rows, t.rows[id])
+	}
+	return rows
+}
+
+func (t *table[T]) find(match func(T) bool) (T, bool) {
+	for _, row := range t.all() {
+		if match(row) {
+			return row, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+func (s *Storage) CreateTag(ctx context.Context, tag models.Tag) (int64, error) {
+	defer s.lock(ctx)()
+
+	if _, exists := s.tagByName(tag.Name); exists {
+		return 0, fmt.Errorf("database error: %w", postgresql.ErrorExists)
+	}
+	id := s.tags.insert(func(id int64) models.Tag {
+		return models.Tag{Id: id, Name: tag.Name}
+	})
+	return id, nil
+}
+
+func (s *Storage) CreateCity(ctx context.Context, city models.City) (int64, error) {
+	defer s.lock(ctx)()
+
+	if _, exists := s.cityByName(city.Name); exists {
+		return 0, fmt.Errorf("database error: %w", postgresql.ErrorExists)
+	}
+	id := s.cities.insert(func(id int64) models.City {
+		return models.City{Id: id, Name: city.Name}
+	})
+	return id, nil
+}
+
+func (s *Storage) ListTags(ctx context.Context) ([]models.Tag, error) {
+	defer s.rlock(ctx)()
+
+	var tags []models.Tag
+	tags = append(tags, s.tags.all()...)
+	return tags, nil
+}
+
+func (s *Storage) ListCities(ctx context.Context) ([]models.City, error) {
+	defer s.rlock(ctx)()
+
+	var cities []models.City
+	cities = append(cities, s.cities.all()...)
+	return cities, nil
+}
+
+func (s *Storage) GetTag(ctx context.Context, id int64) (*models.Tag, error) {
+	defer s.rlock(ctx)()
+
+	tag, ok := s.tags.get(id)
+	if !ok {
+		return nil, fmt.Errorf("database error: %w", postgresql.ErrorNotExists)
+	}
+	return &tag, nil
+}
+
+func (s *Storage) GetCity(ctx context.Context, id int64) (*models.City, error) {
+	defer s.rlock(ctx)()
+
+	city, ok := s.cities.get(id)
+	if !ok {
+		return nil, fmt.Errorf("database error: %w", postgresql.ErrorNotExists)
+	}
+	return &city, nil
+}
+
+// DeleteTag removes the tag from every hotel too.
+func (s *Storage) DeleteTag(ctx context.Context, id int64) error {
+	defer s.lock(ctx)()
+
+	if _, ok := s.tags.get(id); !ok {
+		return fmt.Errorf("deleting: %w", postgresql.ErrorNotExists)
+	}
+	for _, link := range s.tagHotels.all() {
+		if link.TagId == id {
+			s.tagHotels.delete(link.Id)
+		}
+	}
+	s.tags.delete(id)
+	return nil
+}
+
+func (s *Storage) DeleteCity(ctx context.Context, id int64) error {
+	defer s.lock(ctx)()
+
+	if _, ok := s.cities.get(id); !ok {
+		return fmt.Errorf("deleting: %w", postgresql.ErrorNotExists)
+	}
+	if _, used := s.hotels.find(func(h hotelRow) bool { return h.CityId == id }); used {
+		return fmt.Errorf("deleting: %w", postgresql.ErrorInUse)
+	}
+	s.cities.delete(id)
+	return nil
+}
+
+func (s *Storage) CreateHotel(ctx context.Context, hotel models.Hotel, cityName string, tagNames []string) (int64, error) {
+	defer s.lock(ctx)()
+
+	city, ok := s.cityByName(cityName)
+	if !ok {
+		return 0, fmt.Errorf("request error: %w", postgresql.ErrorCityNotExists)
+	}
+	if _, exists := s.hotels.find(func(h hotelRow) bool { return h.Name == hotel.Name }); exists {
+		return 0, fmt.Errorf("database error: %w", postgresql.ErrorExists)
+	}
+	// everything is checked before the first write, so a failure leaves nothing behind
+	tags, err := s.tagsByName(tagNames)
+	if err != nil {
+		return 0, err
+	}
+
+	id := s.hotels.insert(func(id int64) hotelRow {
+		return hotelRow{
+			Id: id,
+			Name: hotel.Name,
+			Desc: hotel.Desc,
+			CityId: city.Id,
+			ManagerId: hotel.ManagerId,
+			Active: true,
+			CreatedAt: s.now(),
+		}
+	})
+	s.linkTags(id, tags)
+	return id, nil
+}
+
+// UpdateHotel changes the given hotel fields and replaces its tags, all or nothing.
+func (s *Storage) UpdateHotel(ctx context.Context, id int64, update models.HotelUpdate) error {
+	defer s.lock(ctx)()
+
+	hotel, ok := s.hotels.get(id)
+	if update.City != nil {
+		city, ok := s.cityByName(*update.City)
+		if !ok {
+			return fmt.Errorf("request error: %w", postgresql.ErrorCityNotExists)
+		}
+		hotel.CityId = city.Id
+	}
+	if !ok || !hotel.Active {
+		return fmt.Errorf("updating: %w", postgresql.ErrorNotExists)
+	}
+	if update.Name != nil {
+		taken := func(h hotelRow) bool { return h.Name == *update.Name && h.Id != id }
+		if _, exists := s.hotels.find(taken); exists {
+			return fmt.Errorf("database error: %w", postgresql.ErrorExists)
+		}
+		hotel.Name = *update.Name
+	}
+	if update.Desc != nil {
+		hotel.Desc = *update.Desc
+	}
+	var tags []models.Tag
+	if update.Tags != nil {
+		var err error
+		if tags, err = s.tagsByName(*update.Tags); err != nil {
+			return err
+		}
+	}
+
+	s.hotels.set(id, hotel)
+	if update.Tags != nil {
+		for _, link := range s.tagHotels.all() {
+			if link.HotelId == id && !slices.ContainsFunc(tags, func(t models.Tag) bool { return t.Id == link.TagId }) {
+				s.tagHotels.delete(link.Id)
+			}
+		}
+		s.linkTags(id, tags)
+	}
+	return nil
+}
+
+// DeleteHotel hides the hotel from public listings, its rooms and bookings are kept.
+func (s *Storage) DeleteHotel(ctx context.Context, id int64) error {
+	defer s.lock(ctx)()
+
+	hotel, ok := s.hotels.get(id)
+	if !ok || !hotel.Active {
+		return fmt.Errorf("deleting: %w", postgresql.ErrorNotExists)
+	}
+	hotel.Active = false
+	s.hotels.set(id, hotel)
+	return nil
+}
+
+func (s *Storage) GetHotelsByManager(ctx context.Context, user_id int64) ([]*models.Hotel, error) {
+	defer s.rlock(ctx)()
+
+	return s.packHotels(func(h hotelRow) bool { return h.ManagerId == user_id && h.Active }), nil
+}
+
+func (s *Storage) GetAllHotes(ctx context.Context) ([]*models.Hotel, error) {
+	defer s.rlock(ctx)()
+
+	return s.packHotels(func(h hotelRow) bool { return h.Active }), nil
+}
+
+func (s *Storage) GetHotel(ctx context.Context, id int64) (*models.Hotel, error) {
+	defer s.rlock(ctx)()
+
+	hotels := s.packHotels(func(h hotelRow) bool { return h.Id == id && h.Active })
+	if len(hotels) == 0 {
+		return nil, fmt.Errorf("database error: %w", postgresql.ErrorNotExists)
+	}
+	return hotels[0], nil
+}
+
+func (s *Storage) GetHotelManager(ctx context.Context, hotelID int64) (int64, error) {
+	defer s.rlock(ctx)()
+
+	// deleted hotels can not be managed anymore
+	hotel, ok := s.hotels.get(hotelID)
+	if !ok || !hotel.Active {
+		return 0, fmt.Errorf("database error: %w", postgresql.ErrorNotExists)
+	}
+	return hotel.ManagerId, nil
+}
+
+// packHotels builds hotels the way the postgresql storage returns them: city and tags by name only.
+func (s *Storage) packHotels(match func(hotelRow) bool) []*models.Hotel {
+	var hotels []*models.Hotel
+	for _, row := range s.hotels.all() {
+		if match(row) {
+			hotels = append(hotels, s.hotelModel(row))
+		}
+	}
+	return hotels
+}
+
+func (s *Storage) hotelModel(row hotelRow) *models.Hotel {
+	city, _ := s.cities.get(row.CityId)
+	hotel := &models.Hotel{
+		Id: row.Id,
+		Name: row.Name,
+		Desc: row.Desc,
+		City: models.City{Name: city.Name},
+		BaseModel: models.BaseModel{
+			CreatedAt: row.CreatedAt,
+			Active: row.Active,
+		},
+	}
+	for _, link := range s.tagHotels.all() {
+		if link.HotelId == row.Id {
+			tag, _ := s.tags.get(link.TagId)
+			hotel.Tags = append(hotel.Tags, models.Tag{Name: tag.Name})
+		}
+	}
+	return hotel
+}
+
+func (s *Storage) tagByName(name string) (models.Tag, bool) {
+	return s.tags.find(func(t models.Tag) bool { return t.Name == name })
+}
+
+func (s *Storage) cityByName(name string) (models.City, bool) {
+	return s.cities.find(func(c models.City) bool { return c.Name == name })
+}
+
+func (s *Storage) tagsByName(names []string) ([]models.Tag, error) {
+	tags := make([]models.Tag, 0, len(names))
+	for _, name := range names {
+		tag, ok := s.tagByName(name)
+		if !ok {
+			return nil, fmt.Errorf("request error: %w", postgresql.ErrorTagNotExists)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// linkTags adds the missing hotel tags, repeated tags are linked once.
+func (s *Storage) linkTags(hotelID int64, tags []models.Tag) {
+	for _, tag := range tags {
+		linked := func(l tagHotelRow) bool { return l.HotelId == hotelID && l.TagId == tag.Id }
+		if _, exists := s.tagHotels.find(linked); exists {
+			continue
+		}
+		s.tagHotels.insert(func(id int64) tagHotelRow {
+			return tagHotelRow{Id: id, HotelId: hotelID, TagId: tag.Id}
+		})
+	}
+}
+
+// containsFold matches like ILIKE '%sub%'.
+func containsFold(s, sub string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(sub))
+}
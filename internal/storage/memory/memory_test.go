@@ -0,0 +1,21 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/Bitummit/booking_api/internal/storage/memory"
+	"github.com/Bitummit/booking_api/internal/storage/storagetest"
+)
+
+func TestStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Backend {
+		s := memory.New()
+		return storagetest.Backend{
+			Storage: s,
+			AddUser: func(t *testing.T, user models.User) int64 {
+				return s.AddUser(user)
+			},
+		}
+	})
+}
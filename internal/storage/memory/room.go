@@ -0,0 +1,155 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/Bitummit/booking_api/internal/storage/postgresql"
+)
+
+func (s *Storage) CreateRoomCategory(ctx context.Context, category models.RoomCategory) (int64, error) {
+	defer s.lock(ctx)()
+
+	if _, ok := s.hotels.get(category.HotelId); !ok {
+		return 0, fmt.Errorf("database error: %w", postgresql.ErrorInsertion)
+	}
+	if s.categoryNameTaken(category) {
+		return 0, fmt.Errorf("database error: %w", postgresql.ErrorExists)
+	}
+	id := s.categories.insert(func(id int64) models.RoomCategory {
+		category.Id = id
+		return category
+	})
+	return id, nil
+}
+
+func (s *Storage) ListRoomCategories(ctx context.Context, hotelID int64) ([]models.RoomCategory, error) {
+	defer s.rlock(ctx)()
+
+	categories := []models.RoomCategory{}
+	for _, category := range s.categories.all() {
+		if category.HotelId == hotelID {
+			categories = append(categories, category)
+		}
+	}
+	return categories, nil
+}
+
+func (s *Storage) GetRoomCategory(ctx context.Context, id int64) (*models.RoomCategory, error) {
+	defer s.rlock(ctx)()
+
+	category, ok := s.categories.get(id)
+	if !ok {
+		return nil, fmt.Errorf("database error: %w", postgresql.ErrorNotExists)
+	}
+	return &category, nil
+}
+
+func (s *Storage) UpdateRoomCategory(ctx context.Context, category models.RoomCategory) error {
+	defer s.lock(ctx)()
+
+	current, ok := s.categories.get(category.Id)
+	if !ok || current.HotelId != category.HotelId {
+		return fmt.Errorf("updating: %w", postgresql.ErrorNotExists)
+	}
+	if s.categoryNameTaken(category) {
+		return fmt.Errorf("database error: %w", postgresql.ErrorExists)
+	}
+	s.categories.set(category.Id, category)
+	return nil
+}
+
+func (s *Storage) DeleteRoomCategory(ctx context.Context, hotelID, id int64) error {
+	defer s.lock(ctx)()
+
+	category, ok := s.categories.get(id)
+	if !ok || category.HotelId != hotelID {
+		return fmt.Errorf("deleting: %w", postgresql.ErrorNotExists)
+	}
+	if _, used := s.rooms.find(func(r models.Room) bool { return r.CategoryId == id }); used {
+		return fmt.Errorf("deleting: %w", postgresql.ErrorInUse)
+	}
+	s.categories.delete(id)
+	return nil
+}
+
+func (s *Storage) categoryNameTaken(category models.RoomCategory) bool {
+	_, taken := s.categories.find(func(c models.RoomCategory) bool {
+		return c.HotelId == category.HotelId && c.Name == category.Name && c.Id != category.Id
+	})
+	return taken
+}
+
+// CreateRoom keeps room numbers unique per hotel, retired rooms included.
+func (s *Storage) CreateRoom(ctx context.Context, hotelID int64, room models.Room) (int64, error) {
+	defer s.lock(ctx)()
+
+	for _, other := range s.rooms.all() {
+		category, _ := s.categories.get(other.CategoryId)
+		if category.HotelId == hotelID && other.Number == room.Number {
+			return 0, fmt.Errorf("database error: %w", postgresql.ErrorExists)
+		}
+	}
+	if _, ok := s.categories.get(room.CategoryId); !ok {
+		return 0, fmt.Errorf("database error: %w", postgresql.ErrorInsertion)
+	}
+	id := s.rooms.insert(func(id int64) models.Room {
+		return models.Room{
+			Id: id,
+			Number: room.Number,
+			CategoryId: room.CategoryId,
+			Active: true,
+		}
+	})
+	return id, nil
+}
+
+func (s *Storage) ListRooms(ctx context.Context, categoryID int64) ([]models.Room, error) {
+	defer s.rlock(ctx)()
+
+	rooms := []models.Room{}
+	for _, room := range s.rooms.all() {
+		if room.CategoryId == categoryID {
+			rooms = append(rooms, room)
+		}
+	}
+	// same order as ORDER BY length(number), number
+	slices.SortStableFunc(rooms, func(a, b models.Room) int {
+		if len(a.Number) != len(b.Number) {
+			return len(a.Number) - len(b.Number)
+		}
+		return strings.Compare(a.Number, b.Number)
+	})
+	return rooms, nil
+}
+
+func (s *Storage) GetRoom(ctx context.Context, id int64) (*models.Room, error) {
+	defer s.rlock(ctx)()
+
+	room, ok := s.rooms.get(id)
+	if !ok {
+		return nil, fmt.Errorf("database error: %w", postgresql.ErrorNotExists)
+	}
+	return &room, nil
+}
+
+// DeleteRoom removes a room that was never booked. Rooms with bookings are only
+// retired, so the booking history keeps pointing at an existing room.
+func (s *Storage) DeleteRoom(ctx context.Context, categoryID, id int64) (bool, error) {
+	defer s.lock(ctx)()
+
+	room, ok := s.rooms.get(id)
+	if !ok || room.CategoryId != categoryID {
+		return false, fmt.Errorf("deleting: %w", postgresql.ErrorNotExists)
+	}
+	if _, booked := s.bookings.find(func(b models.Booking) bool { return b.RoomId == id }); booked {
+		room.Active = false
+		s.rooms.set(id, room)
+		return true, nil
+	}
+	s.rooms.delete(id)
+	return false, nil
+}
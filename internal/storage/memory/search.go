@@ -0,0 +1,174 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Bitummit/booking_api/internal/models"
+)
+
+// hotelSortKey is the sort value of a hotel and its text form kept in cursors. The text form
+// matches the postgresql storage, so cursors work with both backends.
+type hotelSortKey struct {
+	compare func(a, b *searchRow) int
+	text func(row *searchRow) string
+	parse func(value string) (searchRow, error)
+}
+
+type searchRow struct {
+	hotel hotelRow
+	minPrice float64
+}
+
+var hotelSortKeys = map[string]hotelSortKey{
+	"id": {
+		compare: func(a, b *searchRow) int { return cmp.Compare(a.hotel.Id, b.hotel.Id) },
+		text: func(row *searchRow) string { return strconv.FormatInt(row.hotel.Id, 10) },
+		parse: func(value string) (searchRow, error) {
+			id, err := strconv.ParseInt(value, 10, 64)
+			return searchRow{hotel: hotelRow{Id: id}}, err
+		},
+	},
+	"name": {
+		compare: func(a, b *searchRow) int { return strings.Compare(a.hotel.Name, b.hotel.Name) },
+		text: func(row *searchRow) string { return row.hotel.Name },
+		parse: func(value string) (searchRow, error) {
+			return searchRow{hotel: hotelRow{Name: value}}, nil
+		},
+	},
+	"price": {
+		compare: func(a, b *searchRow) int { return cmp.Compare(a.minPrice, b.minPrice) },
+		text: func(row *searchRow) string {
+			if math.IsInf(row.minPrice, 1) {
+				return "Infinity"
+			}
+			return strconv.FormatFloat(row.minPrice, 'f', -1, 64)
+		},
+		parse: func(value string) (searchRow, error) {
+			price, err := strconv.ParseFloat(value, 64)
+			return searchRow{minPrice: price}, err
+		},
+	},
+}
+
+func (s *Storage) SearchHotels(ctx context.Context, filter models.HotelFilter) ([]*models.Hotel, *models.HotelCursor, error) {
+	key, ok := hotelSortKeys[filter.Sort]
+	if !ok {
+		return nil, nil, fmt.Errorf("building query: unknown sort %q", filter.Sort)
+	}
+	compare := func(a, b *searchRow) int {
+		if c := key.compare(a, b); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.hotel.Id, b.hotel.Id)
+	}
+	if filter.Desc {
+		ascending := compare
+		compare = func(a, b *searchRow) int { return ascending(b, a) }
+	}
+
+	var after *searchRow
+	if filter.Cursor != nil {
+		row, err := key.parse(filter.Cursor.Value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("fetching data: %w", err)
+		}
+		row.hotel.Id = filter.Cursor.Id
+		after = &row
+	}
+
+	defer s.rlock(ctx)()
+
+	var rows []*searchRow
+	for _, hotel := range s.hotels.all() {
+		row := &searchRow{hotel: hotel, minPrice: s.minPrice(hotel.Id)}
+		if s.matchesSearch(hotel, filter) && (after == nil || compare(row, after) > 0) {
+			rows = append(rows, row)
+		}
+	}
+	slices.SortFunc(rows, compare)
+
+	var next *models.HotelCursor
+	if len(rows) > filter.Limit {
+		rows = rows[:filter.Limit]
+		last := rows[len(rows)-1]
+		next = &models.HotelCursor{
+			Sort: filter.Sort,
+			Value: key.text(last),
+			Id: last.hotel.Id,
+		}
+	}
+
+	var hotels []*models.Hotel
+	for _, row := range rows {
+		hotel := s.hotelModel(row.hotel)
+		slices.SortFunc(hotel.Tags, func(a, b models.Tag) int { return strings.Compare(a.Name, b.Name) })
+		hotels = append(hotels, hotel)
+	}
+	return hotels, next, nil
+}
+
+func (s *Storage) matchesSearch(hotel hotelRow, filter models.HotelFilter) bool {
+	if !hotel.Active {
+		return false
+	}
+	city, _ := s.cities.get(hotel.CityId)
+	if filter.City != "" && city.Name != filter.City {
+		return false
+	}
+	if filter.Name != "" && !containsFold(hotel.Name, filter.Name) {
+		return false
+	}
+
+	if len(filter.Tags) > 0 {
+		tags := slices.Compact(slices.Sorted(slices.Values(filter.Tags)))
+		matched := 0
+		for _, name := range tags {
+			if s.hotelHasTag(hotel.Id, name) {
+				matched++
+			}
+		}
+		if matched == 0 || filter.AllTags && matched < len(tags) {
+			return false
+		}
+	}
+
+	// price and capacity must be satisfied by the same room category
+	if filter.PriceFrom > 0 || filter.PriceTo > 0 || filter.Capacity > 0 {
+		_, found := s.categories.find(func(c models.RoomCategory) bool {
+			return c.HotelId == hotel.Id &&
+				(filter.PriceFrom <= 0 || c.Price >= filter.PriceFrom) &&
+				(filter.PriceTo <= 0 || c.Price <= filter.PriceTo) &&
+				(filter.Capacity <= 0 || c.Capacity >= filter.Capacity)
+		})
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Storage) hotelHasTag(hotelID int64, name string) bool {
+	tag, ok := s.tagByName(name)
+	if !ok {
+		return false
+	}
+	_, linked := s.tagHotels.find(func(l tagHotelRow) bool { return l.HotelId == hotelID && l.TagId == tag.Id })
+	return linked
+}
+
+// minPrice is the cheapest room category of the hotel, hotels without categories sort last.
+func (s *Storage) minPrice(hotelID int64) float64 {
+	price := math.Inf(1)
+	for _, category := range s.categories.all() {
+		if category.HotelId == hotelID {
+			price = min(price, category.Price)
+		}
+	}
+	return price
+}
@@ -0,0 +1,45 @@
+package memory
+
+import "context"
+
+type txKey struct{}
+
+// WithTx runs fn holding the storage lock, storage methods called with the ctx passed to fn
+// join the transaction. When fn fails every change it made is rolled back. Called inside
+// another transaction it rolls back only its own changes, like a savepoint.
+func (s *Storage) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !s.inTx(ctx) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		ctx = context.WithValue(ctx, txKey{}, s)
+	}
+
+	saved := s.tables.clone()
+	if err := fn(ctx); err != nil {
+		s.tables = saved
+		return err
+	}
+	return nil
+}
+
+func (s *Storage) inTx(ctx context.Context) bool {
+	tx, _ := ctx.Value(txKey{}).(*Storage)
+	return tx == s
+}
+
+// lock takes the write lock unless ctx runs in a transaction, which holds it already.
+func (s *Storage) lock(ctx context.Context) func() {
+	if s.inTx(ctx) {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+func (s *Storage) rlock(ctx context.Context) func() {
+	if s.inTx(ctx) {
+		return func() {}
+	}
+	s.mu.RLock()
+	return s.mu.RUnlock
+}
@@ -0,0 +1,184 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/Bitummit/booking_api/internal/storage/postgresql"
+)
+
+// AddUser stores a user. Users belong to the auth service, in postgres they are rows of its
+// my_user table, so HotelStorage has no method to create them.
+func (s *Storage) AddUser(user models.User) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user.Role == "" {
+		user.Role = models.RoleClient
+	}
+	return s.users.insert(func(id int64) models.User {
+		user.Id = id
+		user.Password = ""
+		return user
+	})
+}
+
+func (s *Storage) ListUsers(ctx context.Context, filter models.UserFilter) ([]models.User, error) {
+	defer s.rlock(ctx)()
+
+	users := []models.User{}
+	for _, user := range s.users.all() {
+		matches := containsFold(user.Username, filter.Query) ||
+			containsFold(user.Email, filter.Query) ||
+			containsFold(user.FirstName+" "+user.LastName, filter.Query)
+		if !matches ||
+			filter.Role != "" && user.Role != filter.Role ||
+			filter.Blocked != nil && user.Blocked != *filter.Blocked {
+			continue
+		}
+		users = append(users, user)
+	}
+	return page(users, filter.Offset, filter.Limit), nil
+}
+
+func (s *Storage) GetUser(ctx context.Context, id int64) (*models.User, error) {
+	defer s.rlock(ctx)()
+
+	user, ok := s.users.get(id)
+	if !ok {
+		return nil, fmt.Errorf("database error: %w", postgresql.ErrorUserNotExists)
+	}
+	return &user, nil
+}
+
+func (s *Storage) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	defer s.rlock(ctx)()
+
+	user, ok := s.users.find(func(u models.User) bool { return u.Username == username })
+	if !ok {
+		return nil, fmt.Errorf("database error: %w", postgresql.ErrorUserNotExists)
+	}
+	return &user, nil
+}
+
+// IsUserBlocked treats unknown users as not blocked.
+func (s *Storage) IsUserBlocked(ctx context.Context, id int64) (bool, error) {
+	defer s.rlock(ctx)()
+
+	user, _ := s.users.get(id)
+	return user.Blocked, nil
+}
+
+func (s *Storage) SetUserBlocked(ctx context.Context, id int64, blocked bool) error {
+	defer s.lock(ctx)()
+
+	user, ok := s.users.get(id)
+	if !ok {
+		return fmt.Errorf("database error: %w", postgresql.ErrorUserNotExists)
+	}
+	user.Blocked = blocked
+	s.users.set(id, user)
+	return nil
+}
+
+func (s *Storage) UpdateUserProfile(ctx context.Context, user models.User) error {
+	defer s.lock(ctx)()
+
+	stored, ok := s.users.get(user.Id)
+	if !ok {
+		return fmt.Errorf("database error: %w", postgresql.ErrorUserNotExists)
+	}
+	stored.FirstName = user.FirstName
+	stored.LastName = user.LastName
+	stored.Email = user.Email
+	stored.Birthday = user.Birthday
+	s.users.set(user.Id, stored)
+	return nil
+}
+
+// TransferHotel changes the hotel manager and records the transfer.
+func (s *Storage) TransferHotel(ctx context.Context, transfer models.HotelTransfer) (*models.HotelTransfer, error) {
+	defer s.lock(ctx)()
+
+	hotel, ok := s.hotels.get(transfer.HotelId)
+	if !ok {
+		return nil, fmt.Errorf("database error: %w", postgresql.ErrorNotExists)
+	}
+	manager, ok := s.users.get(transfer.ToManagerId)
+	if !ok {
+		return nil, fmt.Errorf("database error: %w", postgresql.ErrorUserNotExists)
+	}
+	if manager.Role != models.RoleManager && manager.Role != models.RoleAdmin {
+		return nil, fmt.Errorf("transferring: %w", postgresql.ErrorNotManager)
+	}
+
+	transfer.FromManagerId = hotel.ManagerId
+	transfer.CreatedAt = s.now()
+	transfer.Id = s.transfers.insert(func(id int64) models.HotelTransfer {
+		stored := transfer
+		stored.Id = id
+		return stored
+	})
+	hotel.ManagerId = transfer.ToManagerId
+	s.hotels.set(hotel.Id, hotel)
+	return &transfer, nil
+}
+
+func (s *Storage) ListHotelTransfers(ctx context.Context, hotelID int64) ([]models.HotelTransfer, error) {
+	defer s.rlock(ctx)()
+
+	transfers := []models.HotelTransfer{}
+	for _, transfer := range s.transfers.all() {
+		if transfer.HotelId == hotelID {
+			transfers = append(transfers, transfer)
+		}
+	}
+	slices.SortStableFunc(transfers, func(a, b models.HotelTransfer) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return transfers, nil
+}
+
+func (s *Storage) RecordAudit(ctx context.Context, entry models.AuditEntry) error {
+	defer s.lock(ctx)()
+
+	s.audit.insert(func(id int64) models.AuditEntry {
+		entry.Id = id
+		entry.Before = slices.Clone(entry.Before)
+		entry.After = slices.Clone(entry.After)
+		entry.CreatedAt = s.now()
+		return entry
+	})
+	return nil
+}
+
+func (s *Storage) ListAudit(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	defer s.rlock(ctx)()
+
+	entries := []models.AuditEntry{}
+	for _, entry := range s.audit.all() {
+		if filter.ActorId != 0 && entry.ActorId != filter.ActorId ||
+			filter.EntityType != "" && entry.EntityType != filter.EntityType ||
+			filter.EntityId != 0 && entry.EntityId != filter.EntityId ||
+			filter.From != nil && entry.CreatedAt.Before(*filter.From) ||
+			filter.To != nil && !entry.CreatedAt.Before(*filter.To) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	// newest first, like ORDER BY created_at DESC, id DESC
+	slices.Reverse(entries)
+	slices.SortStableFunc(entries, func(a, b models.AuditEntry) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return page(entries, filter.Offset, filter.Limit), nil
+}
+
+// page applies OFFSET and LIMIT.
+func page[T any](rows []T, offset, limit int) []T {
+	offset = min(max(offset, 0), len(rows))
+	rows = rows[offset:]
+	return rows[:min(max(limit, 0), len(rows))]
+}
@@ -0,0 +1,77 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Storage) RecordAudit(ctx context.Context, entry models.AuditEntry) error {
+	args := pgx.NamedArgs{
+		"actor_id": sql.NullInt64{Int64: entry.ActorId, Valid: entry.ActorId != 0},
+		"action": entry.Action,
+		"entity_type": entry.EntityType,
+		"entity_id": entry.EntityId,
+		"before": nullJSON(entry.Before),
+		"after": nullJSON(entry.After),
+	}
+	if _, err := s.db(ctx).Exec(ctx, CreateAuditEntryStmt, args); err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) ListAudit(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	entries := []models.AuditEntry{}
+	args := pgx.NamedArgs{
+		"actor_id": filter.ActorId,
+		"entity_type": filter.EntityType,
+		"entity_id": filter.EntityId,
+		"from": filter.From,
+		"to": filter.To,
+		"limit": filter.Limit,
+		"offset": filter.Offset,
+	}
+
+	rows, err := s.db(ctx).Query(ctx, ListAuditStmt, args)
+	if err != nil {
+		return nil, fmt.Errorf("fetching data: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.AuditEntry
+		var actorID sql.NullInt64
+		var before, after []byte
+		err = rows.Scan(
+			&entry.Id,
+			&actorID,
+			&entry.Action,
+			&entry.EntityType,
+			&entry.EntityId,
+			&before,
+			&after,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("fetching data: %w", err)
+		}
+		entry.ActorId = actorID.Int64
+		entry.Before = before
+		entry.After = after
+
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fetching data: %w", err)
+	}
+
+	return entries, nil
+}
+
+func nullJSON(data []byte) sql.NullString {
+	return sql.NullString{String: string(data), Valid: len(data) > 0}
+}
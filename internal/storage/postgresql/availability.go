@@ -0,0 +1,69 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// SearchAvailability returns room categories that have at least one room free for the whole stay.
+// The NOT EXISTS probe uses the same predicate as the booking_no_overlap constraint, so it can be
+// answered by the constraint's gist index instead of scanning bookings. BenchmarkSearchAvailability
+// checks the plan of a hotel search against thousands of bookings.
+func (s *Storage) SearchAvailability(ctx context.Context, filter models.AvailabilityFilter) ([]models.HotelAvailability, error) {
+	args := pgx.NamedArgs{
+		"from": filter.From,
+		"to": filter.To,
+		"guests": filter.Guests,
+	}
+	cond := "TRUE"
+	if filter.City != "" {
+		cond = "c.name=@city"
+		args["city"] = filter.City
+	}
+	if filter.HotelId != 0 {
+		cond = "h.id=@hotel_id"
+		args["hotel_id"] = filter.HotelId
+	}
+
+	rows, err := s.db(ctx).Query(ctx, fmt.Sprintf(SearchAvailabilityStmt, cond), args)
+	if err != nil {
+		return nil, fmt.Errorf("fetching data: %w", err)
+	}
+	defer rows.Close()
+
+	hotels := []models.HotelAvailability{}
+	for rows.Next() {
+		var hotel models.Hotel
+		var hotelDesc, categoryDesc sql.NullString
+		var available models.CategoryAvailability
+		category := &available.Category
+
+		err := rows.Scan(
+			&hotel.Id, &hotel.Name, &hotelDesc, &hotel.Active, &hotel.CreatedAt, &hotel.City.Name,
+			&category.Id, &category.Name, &category.Price, &category.Capacity, &categoryDesc, &category.Size,
+			&available.FreeRooms,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+		hotel.Desc = hotelDesc.String
+		category.Desc = categoryDesc.String
+		category.HotelId = hotel.Id
+
+		// rows are ordered by hotel
+		if len(hotels) == 0 || hotels[len(hotels)-1].Hotel.Id != hotel.Id {
+			hotels = append(hotels, models.HotelAvailability{Hotel: hotel})
+		}
+		last := &hotels[len(hotels)-1]
+		last.Categories = append(last.Categories, available)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scanning row: %w", err)
+	}
+
+	return hotels, nil
+}
@@ -0,0 +1,123 @@
+package postgresql_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/Bitummit/booking_api/internal/storage/postgresql"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	benchHotels = 20
+	benchRoomsPerHotel = 25
+	// one three night stay a week for every room, every tenth one is cancelled
+	benchWeeks = 20
+)
+
+// seedAvailabilityStmts fill the storage with benchHotels*benchRoomsPerHotel*benchWeeks bookings.
+var seedAvailabilityStmts = []string{
+	`INSERT INTO my_user(first_name, last_name, username, email, password) VALUES('', '', 'guest', '', '');`,
+	`INSERT INTO city(name) VALUES('Almaty');`,
+	fmt.Sprintf(`
+		INSERT INTO hotel(name, city_id)
+		SELECT 'Hotel ' || h, (SELECT id FROM city) FROM generate_series(1, %d) AS h;
+	`, benchHotels),
+	`
+		INSERT INTO room_category(name, price, capacity, size, hotel_id)
+		SELECT 'Standard', 100, 2, 20, id FROM hotel;
+	`,
+	fmt.Sprintf(`
+		INSERT INTO room(number, category_id)
+		SELECT r::text, rc.id FROM room_category AS rc, generate_series(1, %d) AS r;
+	`, benchRoomsPerHotel),
+	fmt.Sprintf(`
+		INSERT INTO booking(entry_date, leave_date, price, current_status, guests_count, user_id, room_id)
+		SELECT DATE '2025-01-01' + 7*w, DATE '2025-01-01' + 7*w + 3, 300,
+			CAST(CASE WHEN (w + r.id) %% 10 = 0 THEN 'cancelled' ELSE 'submitted' END AS status_enum),
+			2, (SELECT id FROM my_user), r.id
+		FROM room AS r, generate_series(0, %d) AS w;
+	`, benchWeeks-1),
+	`ANALYZE;`,
+}
+
+func seedAvailability(b *testing.B, s *postgresql.Storage) {
+	b.Helper()
+	for _, stmt := range seedAvailabilityStmts {
+		if _, err := s.DB.Exec(context.Background(), stmt); err != nil {
+			b.Fatalf("seeding bookings: %v", err)
+		}
+	}
+}
+
+// explainAvailability returns the plan SearchAvailability gets for the filter.
+func explainAvailability(b *testing.B, s *postgresql.Storage, cond string, args pgx.NamedArgs) string {
+	b.Helper()
+	rows, err := s.DB.Query(context.Background(), "EXPLAIN "+fmt.Sprintf(postgresql.SearchAvailabilityStmt, cond), args)
+	if err != nil {
+		b.Fatalf("explaining: %v", err)
+	}
+	lines, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		b.Fatalf("explaining: %v", err)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func BenchmarkSearchAvailability(b *testing.B) {
+	s := open(b)
+	seedAvailability(b, s)
+	ctx := context.Background()
+	// the stay overlaps the bookings of the week starting on March 5th
+	from, to := time.Date(2025, 3, 6, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC)
+
+	var hotelID int64
+	if err := s.DB.QueryRow(ctx, "SELECT min(id) FROM hotel;").Scan(&hotelID); err != nil {
+		b.Fatalf("getting hotel: %v", err)
+	}
+
+	cases := []struct {
+		name string
+		filter models.AvailabilityFilter
+		cond string
+		args pgx.NamedArgs
+	}{
+		{
+			name: "city",
+			filter: models.AvailabilityFilter{City: "Almaty", From: from, To: to, Guests: 2},
+			cond: "c.name=@city",
+			args: pgx.NamedArgs{"city": "Almaty", "from": from, "to": to, "guests": 2},
+		},
+		{
+			name: "hotel",
+			filter: models.AvailabilityFilter{HotelId: hotelID, From: from, To: to, Guests: 2},
+			cond: "h.id=@hotel_id",
+			args: pgx.NamedArgs{"hotel_id": hotelID, "from": from, "to": to, "guests": 2},
+		},
+	}
+	for _, c := range cases {
+		b.Run(c.name, func(b *testing.B) {
+			plan := explainAvailability(b, s, c.cond, c.args)
+			b.Logf("plan:\n%s", plan)
+			// a single hotel probes few rooms, each probe has to go through the constraint's index
+			if c.name == "hotel" && !strings.Contains(plan, "booking_no_overlap") {
+				b.Fatalf("the booking probe does not use the booking_no_overlap index:\n%s", plan)
+			}
+
+			hotels, err := s.SearchAvailability(ctx, c.filter)
+			if err != nil || len(hotels) == 0 {
+				b.Fatalf("searching availability: got %+v, %v", hotels, err)
+			}
+			b.ResetTimer()
+			for range b.N {
+				if _, err := s.SearchAvailability(ctx, c.filter); err != nil {
+					b.Fatalf("searching availability: %v", err)
+				}
+			}
+		})
+	}
+}
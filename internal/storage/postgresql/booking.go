@@ -0,0 +1,105 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	exclusionViolation = "23P01"
+	maxBookingAttempts = 3
+)
+
+// CreateBooking books the first free room of the category. Overlaps are rejected by the
+// booking_no_overlap constraint, so a room taken by a concurrent request is simply skipped.
+func (s *Storage) CreateBooking(ctx context.Context, booking models.Booking, categoryID int64) (*models.Booking, error) {
+	args := pgx.NamedArgs{
+		"category_id": categoryID,
+		"entry_date": booking.EntryDate,
+		"leave_date": booking.LeaveDate,
+		"price": booking.Price,
+		"status": booking.Status,
+		"guests_count": booking.GuestsCount,
+		"user_id": booking.UserId,
+	}
+
+	for attempt := 0; attempt < maxBookingAttempts; attempt++ {
+		var roomID int64
+		err := s.db(ctx).QueryRow(ctx, FindFreeRoomStmt, args).Scan(&roomID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, fmt.Errorf("database error: %w", ErrorNoFreeRoom)
+			}
+			return nil, fmt.Errorf("database error: %w", err)
+		}
+
+		args["room_id"] = roomID
+		// inside a caller's transaction the savepoint keeps it usable after a lost race
+		err = s.WithTx(ctx, func(ctx context.Context) error {
+			return s.db(ctx).QueryRow(ctx, CreateBookingStmt, args).Scan(&booking.Id)
+		})
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == exclusionViolation {
+				continue
+			}
+			return nil, fmt.Errorf("database error: %w", err)
+		}
+
+		booking.RoomId = roomID
+		return &booking, nil
+	}
+
+	return nil, fmt.Errorf("database error: %w", ErrorNoFreeRoom)
+}
+
+func (s *Storage) GetBooking(ctx context.Context, id int64) (*models.Booking, error) {
+	var booking models.Booking
+	args := pgx.NamedArgs{
+		"id": id,
+	}
+
+	err := s.db(ctx).QueryRow(ctx, GetBookingStmt, args).Scan(
+		&booking.Id,
+		&booking.EntryDate,
+		&booking.LeaveDate,
+		&booking.Price,
+		&booking.Status,
+		&booking.GuestsCount,
+		&booking.UserId,
+		&booking.RoomId,
+		&booking.HotelId,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("database error: %w", ErrorNotExists)
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	return &booking, nil
+}
+
+// UpdateBookingStatus moves the booking only if it is still in the from status.
+func (s *Storage) UpdateBookingStatus(ctx context.Context, id int64, from, to string) error {
+	args := pgx.NamedArgs{
+		"id": id,
+		"from": from,
+		"to": to,
+	}
+
+	resp, err := s.db(ctx).Exec(ctx, UpdateBookingStatusStmt, args)
+	if err != nil {
+		return fmt.Errorf("updating: %w", err)
+	}
+	if resp.RowsAffected() == 0 {
+		return fmt.Errorf("updating: %w", ErrorStatusChanged)
+	}
+
+	return nil
+}
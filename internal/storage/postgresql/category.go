@@ -0,0 +1,166 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const foreignKeyViolation = "23503"
+
+// GetHotelManager returns the manager of an active hotel, deleted hotels can not be managed anymore.
+func (s *Storage) GetHotelManager(ctx context.Context, hotelID int64) (int64, error) {
+	var managerID sql.NullInt64
+	args := pgx.NamedArgs{
+		"id": hotelID,
+	}
+
+	err := s.db(ctx).QueryRow(ctx, GetHotelManagerStmt, args).Scan(&managerID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, fmt.Errorf("database error: %w", ErrorNotExists)
+		}
+		return 0, fmt.Errorf("database error: %w", err)
+	}
+
+	return managerID.Int64, nil
+}
+
+func (s *Storage) CreateRoomCategory(ctx context.Context, category models.RoomCategory) (int64, error) {
+	var id int64
+	args := pgx.NamedArgs{
+		"name": category.Name,
+		"price": category.Price,
+		"capacity": category.Capacity,
+		"desc": category.Desc,
+		"size": category.Size,
+		"hotel_id": category.HotelId,
+	}
+
+	err := s.db(ctx).QueryRow(ctx, CreateRoomCategoryStmt, args).Scan(&id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("database error: %w", ErrorExists)
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, fmt.Errorf("database error: %w", ErrorInsertion)
+		}
+		return 0, fmt.Errorf("database error: %w", err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) ListRoomCategories(ctx context.Context, hotelID int64) ([]models.RoomCategory, error) {
+	categories := []models.RoomCategory{}
+	args := pgx.NamedArgs{
+		"hotel_id": hotelID,
+	}
+
+	rows, err := s.db(ctx).Query(ctx, ListRoomCategoriesStmt, args)
+	if err != nil {
+		return nil, fmt.Errorf("fetching data: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		category, err := scanRoomCategory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("fetching data: %w", err)
+		}
+		categories = append(categories, *category)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fetching data: %w", err)
+	}
+
+	return categories, nil
+}
+
+func (s *Storage) GetRoomCategory(ctx context.Context, id int64) (*models.RoomCategory, error) {
+	args := pgx.NamedArgs{
+		"id": id,
+	}
+
+	category, err := scanRoomCategory(s.db(ctx).QueryRow(ctx, GetRoomCategoryStmt, args))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("database error: %w", ErrorNotExists)
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	return category, nil
+}
+
+func (s *Storage) UpdateRoomCategory(ctx context.Context, category models.RoomCategory) error {
+	args := pgx.NamedArgs{
+		"id": category.Id,
+		"name": category.Name,
+		"price": category.Price,
+		"capacity": category.Capacity,
+		"desc": category.Desc,
+		"size": category.Size,
+		"hotel_id": category.HotelId,
+	}
+
+	resp, err := s.db(ctx).Exec(ctx, UpdateRoomCategoryStmt, args)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("database error: %w", ErrorExists)
+		}
+		return fmt.Errorf("updating: %w", err)
+	}
+	if resp.RowsAffected() == 0 {
+		return fmt.Errorf("updating: %w", ErrorNotExists)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteRoomCategory(ctx context.Context, hotelID, id int64) error {
+	args := pgx.NamedArgs{
+		"id": id,
+		"hotel_id": hotelID,
+	}
+
+	resp, err := s.db(ctx).Exec(ctx, DeleteRoomCategoryStmt, args)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return fmt.Errorf("deleting: %w", ErrorInUse)
+		}
+		return fmt.Errorf("deleting: %w", err)
+	}
+	if resp.RowsAffected() == 0 {
+		return fmt.Errorf("deleting: %w", ErrorNotExists)
+	}
+
+	return nil
+}
+
+func scanRoomCategory(row pgx.Row) (*models.RoomCategory, error) {
+	var category models.RoomCategory
+	var desc sql.NullString
+
+	err := row.Scan(
+		&category.Id,
+		&category.Name,
+		&category.Price,
+		&category.Capacity,
+		&desc,
+		&category.Size,
+		&category.HotelId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("scanning row: %w", err)
+	}
+	category.Desc = desc.String
+
+	return &category, nil
+}
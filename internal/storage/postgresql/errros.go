@@ -1,10 +1,28 @@
 package postgresql
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
 
 var ErrorInsertion = errors.New("can not insert")
 var ErrorExists = errors.New("already exists")
 var ErrorNotExists = errors.New("not exists")
 
 var ErrorTagNotExists = errors.New("no such tag")
-var ErrorCityNotExists = errors.New("no such city")
\ No newline at end of file
+var ErrorCityNotExists = errors.New("no such city")
+var ErrorInUse = errors.New("still referenced")
+var ErrorNoFreeRoom = errors.New("no free room for these dates")
+var ErrorStatusChanged = errors.New("status changed concurrently")
+var ErrorUserNotExists = errors.New("no such user")
+var ErrorNotManager = errors.New("user can not manage hotels")
+
+const uniqueViolation = "23505"
+
+// isUniqueViolation tells that a unique constraint rejected the row, names are kept unique
+// by the schema rather than by a select before the insert.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
@@ -0,0 +1,125 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Bitummit/booking_api/migrations"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+var ErrorSchemaOutdated = errors.New("database schema is older than the code expects")
+
+// MigrationStatus is one embedded migration and whether it is applied.
+type MigrationStatus = goose.MigrationStatus
+
+// migrator runs the embedded migrations over the pool. The session lock keeps several
+// instances started with auto migrate from applying the same migration twice.
+// Closing the provider leaves the pool open.
+func (s *Storage) migrator() (*goose.Provider, error) {
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, fmt.Errorf("creating migration lock: %w", err)
+	}
+	db := stdlib.OpenDBFromPool(s.DB)
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, migrations.FS, goose.WithSessionLocker(locker))
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("loading migrations: %w", err)
+	}
+	return provider, nil
+}
+
+// MigrateUp applies every pending migration and returns the applied versions.
+func (s *Storage) MigrateUp(ctx context.Context) ([]int64, error) {
+	provider, err := s.migrator()
+	if err != nil {
+		return nil, err
+	}
+	defer provider.Close()
+
+	results, err := provider.Up(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("applying migrations: %w", err)
+	}
+	versions := make([]int64, 0, len(results))
+	for _, result := range results {
+		versions = append(versions, result.Source.Version)
+	}
+	return versions, nil
+}
+
+// MigrateDown rolls back the last applied migration and returns its version.
+func (s *Storage) MigrateDown(ctx context.Context) (int64, error) {
+	provider, err := s.migrator()
+	if err != nil {
+		return 0, err
+	}
+	defer provider.Close()
+
+	result, err := provider.Down(ctx)
+	if errors.Is(err, goose.ErrNoNextVersion) {
+		return 0, fmt.Errorf("no migration to roll back")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("rolling back migration: %w", err)
+	}
+	return result.Source.Version, nil
+}
+
+// MigrateRedo rolls back the last applied migration and applies it again.
+func (s *Storage) MigrateRedo(ctx context.Context) (int64, error) {
+	provider, err := s.migrator()
+	if err != nil {
+		return 0, err
+	}
+	defer provider.Close()
+
+	result, err := provider.Down(ctx)
+	if errors.Is(err, goose.ErrNoNextVersion) {
+		return 0, fmt.Errorf("no migration to redo")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("rolling back migration: %w", err)
+	}
+	if _, err := provider.ApplyVersion(ctx, result.Source.Version, true); err != nil {
+		return 0, fmt.Errorf("applying migration: %w", err)
+	}
+	return result.Source.Version, nil
+}
+
+func (s *Storage) MigrationStatus(ctx context.Context) ([]*MigrationStatus, error) {
+	provider, err := s.migrator()
+	if err != nil {
+		return nil, err
+	}
+	defer provider.Close()
+
+	status, err := provider.Status(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting migration status: %w", err)
+	}
+	return status, nil
+}
+
+// CheckSchemaVersion fails with ErrorSchemaOutdated when an embedded migration is not applied.
+// A newer schema is fine, it is what an instance of the previous release sees during a deploy.
+func (s *Storage) CheckSchemaVersion(ctx context.Context) error {
+	provider, err := s.migrator()
+	if err != nil {
+		return err
+	}
+	defer provider.Close()
+
+	current, target, err := provider.GetVersions(ctx)
+	if err != nil {
+		return fmt.Errorf("getting schema version: %w", err)
+	}
+	if current < target {
+		return fmt.Errorf("%w: version %d, expected %d", ErrorSchemaOutdated, current, target)
+	}
+	return nil
+}
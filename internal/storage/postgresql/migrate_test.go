@@ -0,0 +1,117 @@
+package postgresql_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Bitummit/booking_api/migrations"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+)
+
+const (
+	auditLogVersion = 20241208120000
+	schemaFixesVersion = 20241209120000
+)
+
+// newDatabase creates an empty database in the cluster, the shared one is migrated already.
+func newDatabase(t *testing.T, name string) *pgxpool.Pool {
+	t.Helper()
+	if cluster == nil {
+		t.Skip(unavailable)
+	}
+	ctx := context.Background()
+	if _, err := cluster.Storage.DB.Exec(ctx, "CREATE DATABASE "+name+";"); err != nil {
+		t.Fatalf("creating database: %v", err)
+	}
+	db, err := pgxpool.New(ctx, strings.Replace(cluster.URL, "/postgres?", "/"+name+"?", 1))
+	if err != nil {
+		t.Fatalf("connecting to database: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		cluster.Storage.DB.Exec(context.Background(), "DROP DATABASE "+name+";")
+	})
+	return db
+}
+
+// dump returns the rows of the query with their columns separated by spaces.
+func dump(t *testing.T, db *pgxpool.Pool, query string) []string {
+	t.Helper()
+	rows, err := db.Query(context.Background(), query)
+	if err != nil {
+		t.Fatalf("querying %s: %v", query, err)
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			t.Fatalf("scanning %s: %v", query, err)
+		}
+		out = append(out, strings.TrimSpace(fmt.Sprintln(values...)))
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("querying %s: %v", query, err)
+	}
+	return out
+}
+
+func TestSchemaFixesCleansUpDuplicates(t *testing.T) {
+	db := newDatabase(t, "schema_fixes")
+	ctx := context.Background()
+	sqlDB := stdlib.OpenDBFromPool(db)
+	defer sqlDB.Close()
+	provider, err := goose.NewProvider(goose.DialectPostgres, sqlDB, migrations.FS)
+	if err != nil {
+		t.Fatalf("loading migrations: %v", err)
+	}
+	if _, err := provider.UpTo(ctx, auditLogVersion); err != nil {
+		t.Fatalf("migrating to %d: %v", auditLogVersion, err)
+	}
+
+	// names that raced past the select checks, the capacity column still starts with a cyrillic "с"
+	seed := `
+		INSERT INTO city(name) VALUES('Almaty'), ('Almaty'), ('Astana');
+		INSERT INTO tag(name) VALUES('wifi'), ('wifi'), ('pool');
+		INSERT INTO hotel(name, city_id) VALUES('Lake', 1), ('Lake', 2), ('River', 3);
+		INSERT INTO tag_hotel(hotel_id, tag_id) VALUES(1, 1), (1, 2), (2, 2), (3, 3);
+		INSERT INTO room_category(name, price, сapacity, size, hotel_id)
+		VALUES('Standard', 100, 2, 20, 1), ('Standard', 200, 2, 20, 1), ('Standard', 100, 2, 20, 2);
+	`
+	if _, err := db.Exec(ctx, seed); err != nil {
+		t.Fatalf("seeding duplicates: %v", err)
+	}
+	if _, err := provider.UpTo(ctx, schemaFixesVersion); err != nil {
+		t.Fatalf("migrating to %d: %v", schemaFixesVersion, err)
+	}
+
+	for _, tt := range []struct {
+		query string
+		want []string
+	}{
+		// tags and cities are merged into the oldest row
+		{"SELECT id, name FROM city ORDER BY id;", []string{"1 Almaty", "3 Astana"}},
+		{"SELECT id, name FROM tag ORDER BY id;", []string{"1 wifi", "3 pool"}},
+		{"SELECT hotel_id, tag_id FROM tag_hotel ORDER BY hotel_id, tag_id;", []string{"1 1", "2 1", "3 3"}},
+		{"SELECT id, name, city_id FROM hotel ORDER BY id;", []string{"1 Lake 1", "2 Lake (2) 1", "3 River 3"}},
+		// hotels and categories are renamed, they keep their own rooms
+		{"SELECT id, name, hotel_id FROM room_category ORDER BY id;", []string{"1 Standard 1", "2 Standard (2) 1", "3 Standard 2"}},
+	} {
+		if got := dump(t, db, tt.query); !slices.Equal(got, tt.want) {
+			t.Errorf("%s\ngot  %q\nwant %q", tt.query, got, tt.want)
+		}
+	}
+
+	// the constraints are in place
+	_, err = db.Exec(ctx, "INSERT INTO tag(name) VALUES('wifi');")
+	if pgErr := (*pgconn.PgError)(nil); !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+		t.Errorf("duplicate tag after the migration: got %v, want a unique violation", err)
+	}
+}
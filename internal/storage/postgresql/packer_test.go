@@ -0,0 +1,54 @@
+package postgresql
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/Bitummit/booking_api/internal/models"
+)
+
+func TestHotelPacker(t *testing.T) {
+	tag := func(name string) sql.NullString {
+		return sql.NullString{String: name, Valid: true}
+	}
+	a := models.Hotel{Id: 1, Name: "A"}
+	b := models.Hotel{Id: 2, Name: "B"}
+	c := models.Hotel{Id: 3, Name: "C"}
+
+	packer := newHotelPacker()
+	// rows of one hotel do not have to be next to each other
+	packer.add(a, tag("wifi"))
+	packer.add(b, sql.NullString{})
+	packer.add(a, tag("pool"))
+	packer.add(c, tag("spa"))
+	packer.add(a, tag("spa"))
+
+	want := []struct {
+		name string
+		tags []string
+	}{
+		{"A", []string{"wifi", "pool", "spa"}},
+		{"B", nil},
+		{"C", []string{"spa"}},
+	}
+	if len(packer.hotels) != len(want) {
+		t.Fatalf("got %d hotels, want %d", len(packer.hotels), len(want))
+	}
+	for i, hotel := range packer.hotels {
+		if hotel.Name != want[i].name {
+			t.Fatalf("hotel %d: got %s, want %s, first rows keep their order", i, hotel.Name, want[i].name)
+		}
+		var tags []string
+		for _, tag := range hotel.Tags {
+			tags = append(tags, tag.Name)
+		}
+		if len(tags) != len(want[i].tags) {
+			t.Fatalf("hotel %s: got tags %v, want %v", hotel.Name, tags, want[i].tags)
+		}
+		for j := range tags {
+			if tags[j] != want[i].tags[j] {
+				t.Fatalf("hotel %s: got tags %v, want %v", hotel.Name, tags, want[i].tags)
+			}
+		}
+	}
+}
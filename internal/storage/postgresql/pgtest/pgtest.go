@@ -0,0 +1,229 @@
+// Package pgtest runs tests against a throwaway postgres cluster. Start creates the cluster
+// with initdb in a temp dir and applies the migrations, Open hands an empty storage to every
+// test case. Nothing but the local postgres binaries is needed, so it works offline.
+//
+//	func TestMain(m *testing.M) {
+//		cluster, err := pgtest.Start(context.Background())
+//		...
+//		code := m.Run()
+//		cluster.Stop()
+//		os.Exit(code)
+//	}
+package pgtest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/Bitummit/booking_api/internal/storage/postgresql"
+	"github.com/Bitummit/booking_api/internal/storage/storagetest"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrorUnavailable means no cluster can be started on this box, tests should be skipped.
+var ErrorUnavailable = errors.New("postgres binaries are unavailable")
+
+const (
+	ListTablesStmt = "SELECT tablename FROM pg_tables WHERE schemaname='public' AND tablename<>'goose_db_version';"
+	CreateUserStmt = `
+		INSERT INTO my_user(first_name, last_name, username, email, password, birthday, role, blocked)
+		VALUES(@first_name, @last_name, @username, @email, @password, @birthday, @role, @blocked)
+		RETURNING id;
+	`
+)
+
+// Cluster is a running postgres cluster with the schema migrated. Open truncates the shared
+// database, so tests using one cluster must not run in parallel.
+type Cluster struct {
+	URL string
+	Storage *postgresql.Storage
+
+	dir string
+	pgCtl string
+	tables []string
+	// asOwner makes a command run as the owner of the cluster files
+	asOwner func(cmd *exec.Cmd)
+}
+
+// Start runs initdb and pg_ctl from PG_BIN, PATH or /usr/lib/postgresql/*/bin. Run as root
+// it starts the cluster as the postgres or nobody account.
+func Start(ctx context.Context) (*Cluster, error) {
+	bin, err := findBin()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "pgtest")
+	if err != nil {
+		return nil, fmt.Errorf("creating cluster dir: %w", err)
+	}
+	asOwner, err := dropPrivileges(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	c := &Cluster{
+		dir: dir,
+		pgCtl: filepath.Join(bin, "pg_ctl"),
+		asOwner: asOwner,
+	}
+
+	port, err := freePort()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	data := filepath.Join(dir, "data")
+	initdb := c.command(ctx, filepath.Join(bin, "initdb"), "-D", data, "-U", "postgres", "-A", "trust", "-E", "UTF8", "--no-sync")
+	if out, err := initdb.CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("initdb: %w: %s", err, out)
+	}
+	// fsync is off and the socket stays in the cluster dir, the cluster is thrown away anyway
+	options := fmt.Sprintf("-h 127.0.0.1 -p %d -k %s -F", port, dir)
+	start := c.command(ctx, c.pgCtl, "start", "-w", "-D", data, "-l", filepath.Join(dir, "postgres.log"), "-o", options)
+	if out, err := start.CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("starting postgres: %w: %s", err, out)
+	}
+	c.URL = fmt.Sprintf("postgres://postgres@127.0.0.1:%d/postgres?sslmode=disable", port)
+
+	if err := c.connect(ctx); err != nil {
+		c.Stop()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Cluster) connect(ctx context.Context) error {
+	db, err := pgxpool.New(ctx, c.URL)
+	if err != nil {
+		return fmt.Errorf("connecting to db: %w", err)
+	}
+	c.Storage = &postgresql.Storage{DB: db}
+
+	if _, err := c.Storage.MigrateUp(ctx); err != nil {
+		return fmt.Errorf("migrating: %w", err)
+	}
+	rows, err := db.Query(ctx, ListTablesStmt)
+	if err != nil {
+		return fmt.Errorf("listing tables: %w", err)
+	}
+	c.tables, err = pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("listing tables: %w", err)
+	}
+	return nil
+}
+
+// Stop shuts the cluster down and removes its files.
+func (c *Cluster) Stop() error {
+	if c.Storage != nil {
+		c.Storage.DB.Close()
+	}
+	var err error
+	out, stopErr := c.command(context.Background(), c.pgCtl, "stop", "-D", filepath.Join(c.dir, "data"), "-m", "immediate").CombinedOutput()
+	if stopErr != nil {
+		err = fmt.Errorf("stopping postgres: %w: %s", stopErr, out)
+	}
+	return errors.Join(err, os.RemoveAll(c.dir))
+}
+
+// command runs in the cluster dir, the owner may not be allowed into the working directory.
+func (c *Cluster) command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = c.dir
+	c.asOwner(cmd)
+	return cmd
+}
+
+// Open empties every table and returns the storage. The schema and goose versions are kept,
+// serials start from 1 again.
+func (c *Cluster) Open(t testing.TB) *postgresql.Storage {
+	t.Helper()
+	if len(c.tables) > 0 {
+		stmt := "TRUNCATE " + strings.Join(c.tables, ", ") + " RESTART IDENTITY CASCADE;"
+		if _, err := c.Storage.DB.Exec(context.Background(), stmt); err != nil {
+			t.Fatalf("truncating tables: %v", err)
+		}
+	}
+	return c.Storage
+}
+
+// Backend opens the storage for the storagetest contract suite.
+func (c *Cluster) Backend(t *testing.T) storagetest.Backend {
+	return storagetest.Backend{
+		Storage: c.Open(t),
+		AddUser: c.AddUser,
+	}
+}
+
+// AddUser inserts a user into my_user, the table the auth service owns. A zero Birthday is
+// stored as NULL, like for users registered through the auth service.
+func (c *Cluster) AddUser(t *testing.T, user models.User) int64 {
+	t.Helper()
+	if user.Role == "" {
+		user.Role = models.RoleClient
+	}
+	args := pgx.NamedArgs{
+		"first_name": user.FirstName,
+		"last_name": user.LastName,
+		"username": user.Username,
+		"email": user.Email,
+		"password": user.Password,
+		"birthday": sql.NullTime{Time: user.Birthday, Valid: !user.Birthday.IsZero()},
+		"role": string(user.Role),
+		"blocked": user.Blocked,
+	}
+	var id int64
+	if err := c.Storage.DB.QueryRow(context.Background(), CreateUserStmt, args).Scan(&id); err != nil {
+		t.Fatalf("creating user %s: %v", user.Username, err)
+	}
+	return id
+}
+
+func findBin() (string, error) {
+	if bin := os.Getenv("PG_BIN"); bin != "" {
+		return bin, nil
+	}
+	if initdb, err := exec.LookPath("initdb"); err == nil {
+		return filepath.Dir(initdb), nil
+	}
+	// debian and ubuntu keep the server binaries off PATH, the newest version wins
+	dirs, _ := filepath.Glob("/usr/lib/postgresql/*/bin")
+	slices.SortFunc(dirs, func(a, b string) int {
+		return majorVersion(a) - majorVersion(b)
+	})
+	for _, dir := range slices.Backward(dirs) {
+		if _, err := os.Stat(filepath.Join(dir, "initdb")); err == nil {
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("%w: set PG_BIN or put initdb on PATH", ErrorUnavailable)
+}
+
+func majorVersion(binDir string) int {
+	version, _ := strconv.Atoi(filepath.Base(filepath.Dir(binDir)))
+	return version
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("finding free port: %w", err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package pgtest
+
+import "os/exec"
+
+func dropPrivileges(string) (func(cmd *exec.Cmd), error) {
+	return func(*exec.Cmd) {}, nil
+}
@@ -0,0 +1,46 @@
+//go:build unix
+
+package pgtest
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// dropPrivileges returns how to run the postgres binaries. initdb and postgres refuse to run
+// as root, so root hands dir over to the postgres account, or nobody when there is none, and
+// runs them as that account.
+func dropPrivileges(dir string) (func(cmd *exec.Cmd), error) {
+	if os.Geteuid() != 0 {
+		return func(*exec.Cmd) {}, nil
+	}
+
+	account, err := user.Lookup("postgres")
+	if err != nil {
+		account, err = user.Lookup("nobody")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: no account to run postgres as root: %w", ErrorUnavailable, err)
+	}
+	uid, err := strconv.ParseUint(account.Uid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("parsing uid of %s: %w", account.Username, err)
+	}
+	gid, err := strconv.ParseUint(account.Gid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("parsing gid of %s: %w", account.Username, err)
+	}
+	if err := os.Chown(dir, int(uid), int(gid)); err != nil {
+		return nil, fmt.Errorf("handing cluster dir to %s: %w", account.Username, err)
+	}
+
+	return func(cmd *exec.Cmd) {
+		cmd.SysProcAttr = &syscall.SysProcAttr{
+			Credential: &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)},
+		}
+	}, nil
+}
@@ -4,13 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log/slog"
 	"os"
+	"slices"
 	"time"
 	"database/sql"
 
 	"github.com/Bitummit/booking_api/internal/models"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -38,19 +39,15 @@ func New(ctx context.Context) (*Storage, error){
 
 func (s *Storage) CreateTag(ctx context.Context, tag models.Tag) (int64, error) {
 	var id int64
-	checkStmt := GetTagByName
 	args := pgx.NamedArgs{
 		"name": tag.Name,
 	}
 
-	err := s.DB.QueryRow(ctx, checkStmt, args).Scan(&id)
-	if err == nil {
-		return 0, fmt.Errorf("database error: %w", ErrorExists)
-	}
-
-	createStmt := CreateTagStmt
-	err = s.DB.QueryRow(ctx, createStmt, args).Scan(&id)
+	err := s.db(ctx).QueryRow(ctx, CreateTagStmt, args).Scan(&id)
 	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("database error: %w", ErrorExists)
+		}
 		if errors.Is(err, pgx.ErrNoRows) {
 			return 0, fmt.Errorf("database error: %w", ErrorInsertion)
 		}
@@ -62,23 +59,17 @@ func (s *Storage) CreateTag(ctx context.Context, tag models.Tag) (int64, error)
 
 func (s *Storage) CreateCity(ctx context.Context, city models.City) (int64, error) {
 	var id int64
-	checkStmt := GetCityByName
 	args := pgx.NamedArgs{
 		"name": city.Name,
 	}
 
-	err := s.DB.QueryRow(ctx, checkStmt, args).Scan(&id)
-	if err == nil {
-		return 0, fmt.Errorf("database error: %w", ErrorExists)
-	}
-
-	stmt := CreateCityStmt
-	err = s.DB.QueryRow(ctx, stmt, args).Scan(&id)
+	err := s.db(ctx).QueryRow(ctx, CreateCityStmt, args).Scan(&id)
 	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("database error: %w", ErrorExists)
+		}
 		if errors.Is(err, pgx.ErrNoRows) {
 			return 0, fmt.Errorf("database error: %w", ErrorInsertion)
-		} else if errors.Is(err, pgx.ErrTooManyRows) {
-			return 0, fmt.Errorf("database error: %w", ErrorExists)
 		}
 		return 0, fmt.Errorf("database error: %w", err)
 	}
@@ -90,10 +81,11 @@ func (s *Storage) ListTags(ctx context.Context) ([]models.Tag, error) {
 	stmt := ListTagsStmt
 	var tags []models.Tag
 
-	rows, err := s.DB.Query(ctx, stmt)
+	rows, err := s.db(ctx).Query(ctx, stmt)
 	if err != nil {
 		return nil, fmt.Errorf("fetching data: %w", err)
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var tag models.Tag
@@ -112,10 +104,11 @@ func (s *Storage) ListCities(ctx context.Context) ([]models.City, error) {
 	stmt := ListCitiesStmt
 	var cities []models.City
 
-	rows, err := s.DB.Query(ctx, stmt)
+	rows, err := s.db(ctx).Query(ctx, stmt)
 	if err != nil {
 		return nil, fmt.Errorf("fetching data: %w", err)
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var city models.City
@@ -130,15 +123,40 @@ func (s *Storage) ListCities(ctx context.Context) ([]models.City, error) {
 	return cities, nil
 }
 
+func (s *Storage) GetTag(ctx context.Context, id int64) (*models.Tag, error) {
+	var tag models.Tag
+	err := s.db(ctx).QueryRow(ctx, GetTagStmt, pgx.NamedArgs{"id": id}).Scan(&tag.Id, &tag.Name)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("database error: %w", ErrorNotExists)
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return &tag, nil
+}
+
+func (s *Storage) GetCity(ctx context.Context, id int64) (*models.City, error) {
+	var city models.City
+	err := s.db(ctx).QueryRow(ctx, GetCityStmt, pgx.NamedArgs{"id": id}).Scan(&city.Id, &city.Name)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("database error: %w", ErrorNotExists)
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return &city, nil
+}
+
 func (s *Storage) DeleteTag(ctx context.Context, id int64) error {
 	stmt := DeleteTagStmt
 	args := pgx.NamedArgs{
 		"id": id,
 	}
 
-	resp, err := s.DB.Exec(ctx, stmt, args)
+	// hotels lose the tag, tag_hotel rows are deleted in cascade
+	resp, err := s.db(ctx).Exec(ctx, stmt, args)
 	if err != nil {
-		return fmt.Errorf("no such tag")
+		return fmt.Errorf("deleting err: %w", err)
 	}
 	if resp.RowsAffected() == 0 {
 		return fmt.Errorf("deleting: %w", ErrorNotExists)
@@ -153,8 +171,12 @@ func (s *Storage) DeleteCity(ctx context.Context, id int64) error {
 		"id": id,
 	}
 
-	resp, err := s.DB.Exec(ctx, stmt, args)
+	resp, err := s.db(ctx).Exec(ctx, stmt, args)
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return fmt.Errorf("deleting: %w", ErrorInUse)
+		}
 		return fmt.Errorf("deleting err: %w", err)
 	}
 	if resp.RowsAffected() == 0 {
@@ -166,18 +188,8 @@ func (s *Storage) DeleteCity(ctx context.Context, id int64) error {
 
 func (s *Storage) CreateHotel(ctx context.Context, hotel models.Hotel, cityName string, tagNames []string) (int64, error) {
 	var id int64
-	rollback := false
-	
-	stmt := CheckHotelNameUniqueStmt // check if hotel already exists
-	args := pgx.NamedArgs{
-		"name": hotel.Name,
-	}
-	err := s.DB.QueryRow(ctx, stmt, args).Scan(&id)
-	if err == nil {
-		return 0, fmt.Errorf("database error: %w", ErrorExists)
-	}
 
-	resp, err := s.DB.Exec(ctx, GetCityByName, pgx.NamedArgs{"name": cityName}) // check if city exists
+	resp, err := s.db(ctx).Exec(ctx, GetCityByName, pgx.NamedArgs{"name": cityName}) // check if city exists
 	if err != nil {
 		return 0, fmt.Errorf("database internal error: %w", err)
 	}
@@ -185,67 +197,142 @@ func (s *Storage) CreateHotel(ctx context.Context, hotel models.Hotel, cityName
 		return 0, fmt.Errorf("request error: %w", ErrorCityNotExists)
 	}
 
-	tx, err := s.DB.BeginTx(ctx, pgx.TxOptions{}) // init transaction
+	// the hotel is kept only together with all of its tags
+	err = s.WithTx(ctx, func(ctx context.Context) error {
+		args := pgx.NamedArgs{
+			"name": hotel.Name,
+			"desc": hotel.Desc,
+			"city_name": cityName,
+			"manager_id": hotel.ManagerId,
+		}
+		err := s.db(ctx).QueryRow(ctx, CreateHotelStmt, args).Scan(&id)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return fmt.Errorf("database error: %w", ErrorExists)
+			}
+			return fmt.Errorf("database internal error: %w", err)
+		}
+
+		for _, tag := range tagNames {
+			if err := s.CreateTagHotel(ctx, tag, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return 0, fmt.Errorf("database internal error: %w", err)
+		return 0, err
 	}
-	defer func() {
-        if rollback || err != nil{
-			slog.Info("rollback")
-            tx.Rollback(ctx)
-        } else {
-			slog.Info("commit")
-            tx.Commit(ctx)
-        }
-    }()
-
-	stmt = CreateHotelStmt // creating hotel
-	args = pgx.NamedArgs{
-		"name": hotel.Name,
-		"desc": hotel.Desc,
-		"city_name": cityName,
-	}
-	err = tx.QueryRow(ctx, stmt, args).Scan(&id)
+
+	return id, nil
+}
+
+// CreateTagHotel links an existing tag to the hotel, linking it twice is a no-op.
+func (s *Storage) CreateTagHotel(ctx context.Context, tagName string, hotelID int64) error {
+	resp, err := s.db(ctx).Exec(ctx, GetTagByName, pgx.NamedArgs{"name": tagName})
 	if err != nil {
-		// check if not city
-		rollback = true
-		return 0, fmt.Errorf("database internal error: %w", err)
+		return fmt.Errorf("database internal error: %w", err)
+	}
+	if resp.RowsAffected() == 0 {
+		return fmt.Errorf("request error: %w", ErrorTagNotExists)
 	}
 
-	for _, tag := range tagNames {
-		resp, err := tx.Exec(ctx, GetTagByName, pgx.NamedArgs{"name": tag})
+	args := pgx.NamedArgs{
+		"hotel_id": hotelID,
+		"tag_name": tagName,
+	}
+	_, err = s.db(ctx).Exec(ctx, CreateTagHotelStmt, args)
+	if err != nil {
+		return fmt.Errorf("creating ref hotel_id and tag_id: %w: %w", ErrorInsertion, err)
+	}
+	return nil
+}
+
+// UpdateHotel changes the given hotel fields and replaces its tags in one transaction.
+func (s *Storage) UpdateHotel(ctx context.Context, id int64, update models.HotelUpdate) error {
+	if update.City != nil {
+		resp, err := s.db(ctx).Exec(ctx, GetCityByName, pgx.NamedArgs{"name": *update.City})
 		if err != nil {
-			rollback = true
-			return 0, fmt.Errorf("database internal error: %w", err)
+			return fmt.Errorf("database internal error: %w", err)
 		}
 		if resp.RowsAffected() == 0 {
-			rollback = true
-			return 0, fmt.Errorf("request error: %w", ErrorTagNotExists)
+			return fmt.Errorf("request error: %w", ErrorCityNotExists)
 		}
+	}
 
-		err = s.CreateTagHotel(ctx, tag, id, tx)
+	return s.WithTx(ctx, func(ctx context.Context) error {
+		args := pgx.NamedArgs{
+			"id": id,
+			"name": update.Name,
+			"desc": update.Desc,
+			"city_name": update.City,
+		}
+		resp, err := s.db(ctx).Exec(ctx, UpdateHotelStmt, args)
 		if err != nil {
-			rollback = true
-			return 0, fmt.Errorf("%w", err)
+			if isUniqueViolation(err) {
+				return fmt.Errorf("database error: %w", ErrorExists)
+			}
+			return fmt.Errorf("database internal error: %w", err)
+		}
+		if resp.RowsAffected() == 0 {
+			return fmt.Errorf("updating: %w", ErrorNotExists)
 		}
-	}
 
-	return id, nil
+		if update.Tags != nil {
+			return s.replaceHotelTags(ctx, id, *update.Tags)
+		}
+		return nil
+	})
 }
 
-func (s *Storage) CreateTagHotel(ctx context.Context, tagName string, hotelID int64, tx pgx.Tx) error {
-	args := pgx.NamedArgs{
-		"hotel_id": hotelID,
-		"tag_name": tagName,
+// replaceHotelTags diffs the current hotel tags against tagNames and only touches the changed ones.
+func (s *Storage) replaceHotelTags(ctx context.Context, hotelID int64, tagNames []string) error {
+	rows, err := s.db(ctx).Query(ctx, ListHotelTagNamesStmt, pgx.NamedArgs{"hotel_id": hotelID})
+	if err != nil {
+		return fmt.Errorf("database internal error: %w", err)
 	}
-	_, err := tx.Exec(ctx, CreateTagHotelStmt, args)
+	current, err := pgx.CollectRows(rows, pgx.RowTo[string])
 	if err != nil {
-		return fmt.Errorf("creating ref hotel_id and tag_id: %w", ErrorInsertion)
+		return fmt.Errorf("database internal error: %w", err)
 	}
+
+	for _, tag := range current {
+		if slices.Contains(tagNames, tag) {
+			continue
+		}
+		_, err := s.db(ctx).Exec(ctx, DeleteTagHotelStmt, pgx.NamedArgs{"hotel_id": hotelID, "tag_name": tag})
+		if err != nil {
+			return fmt.Errorf("database internal error: %w", err)
+		}
+	}
+
+	for _, tag := range tagNames {
+		if slices.Contains(current, tag) {
+			continue
+		}
+		if err = s.CreateTagHotel(ctx, tag, hotelID); err != nil {
+			return err
+		}
+		current = append(current, tag)
+	}
+
 	return nil
 }
 
-func (s *Storage) UpdateUserRole(ctx context.Context, username string) error {
+// DeleteHotel hides the hotel from public listings, its rooms and bookings are kept.
+func (s *Storage) DeleteHotel(ctx context.Context, id int64) error {
+	args := pgx.NamedArgs{
+		"id": id,
+	}
+
+	resp, err := s.db(ctx).Exec(ctx, SoftDeleteHotelStmt, args)
+	if err != nil {
+		return fmt.Errorf("deleting err: %w", err)
+	}
+	if resp.RowsAffected() == 0 {
+		return fmt.Errorf("deleting: %w", ErrorNotExists)
+	}
+
 	return nil
 }
 
@@ -255,7 +342,7 @@ func (s *Storage) GetHotelsByManager(ctx context.Context, user_id int64) ([]*mod
 		"user_id": user_id,
 	}
 
-	rows, err := s.DB.Query(ctx, stmt, args)
+	rows, err := s.db(ctx).Query(ctx, stmt, args)
 	if err != nil {
 		return nil, fmt.Errorf("fetching data: %w", err)
 	}
@@ -272,7 +359,7 @@ func (s *Storage) GetHotelsByManager(ctx context.Context, user_id int64) ([]*mod
 func (s *Storage) GetAllHotes(ctx context.Context) ([]*models.Hotel, error) {
 	stmt := GetAllHotelsStmt
 
-	rows, err := s.DB.Query(ctx, stmt)
+	rows, err := s.db(ctx).Query(ctx, stmt)
 	if err != nil {
 		return nil, fmt.Errorf("fetching data: %w", err)
 	}
@@ -286,37 +373,74 @@ func (s *Storage) GetAllHotes(ctx context.Context) ([]*models.Hotel, error) {
 	return hotels, nil
 }
 
+func (s *Storage) GetHotel(ctx context.Context, id int64) (*models.Hotel, error) {
+	args := pgx.NamedArgs{
+		"id": id,
+	}
+
+	rows, err := s.db(ctx).Query(ctx, GetHotelStmt, args)
+	if err != nil {
+		return nil, fmt.Errorf("fetching data: %w", err)
+	}
+	defer rows.Close()
+
+	hotels, err := packHotels(rows)
+	if err != nil {
+		return nil, fmt.Errorf("parsing data: %w", err)
+	}
+	if len(hotels) == 0 {
+		return nil, fmt.Errorf("database error: %w", ErrorNotExists)
+	}
+
+	return hotels[0], nil
+}
 
 func packHotels(rows pgx.Rows) ([]*models.Hotel, error){
-	var hotels []*models.Hotel
-	hotelsMap := make(map[int64]*models.Hotel)
-	
+	packer := newHotelPacker()
+
 	for rows.Next() {
 		var hotel models.Hotel
-		var tag models.Tag
 		var tagName sql.NullString
 		var hotelDesc sql.NullString
 		var city models.City
 
-		err := rows.Scan(&hotel.Id, &hotel.Name, &hotelDesc, &city.Name, &tagName)
+		err := rows.Scan(&hotel.Id, &hotel.Name, &hotelDesc, &hotel.Active, &hotel.CreatedAt, &city.Name, &tagName)
 		if err != nil {
 			return nil, fmt.Errorf("scanning row: %w", err)
 		}
 		hotel.Desc = hotelDesc.String
+		hotel.City = city
 
-		if _, exists := hotelsMap[hotel.Id]; !exists {
-			hotelsMap[hotel.Id] = &hotel
-		}
-		if tagName.Valid {
-			tag.Name = tagName.String
-			hotelsMap[hotel.Id].Tags = append(hotelsMap[hotel.Id].Tags, tag)
-		}
-		hotelsMap[hotel.Id].City = city
-
+		packer.add(hotel, tagName)
 	}
-	for _, hotel := range hotelsMap{
-		hotels = append(hotels, hotel)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scanning row: %w", err)
 	}
 
-	return hotels, nil
-}
\ No newline at end of file
+	return packer.hotels, nil
+}
+
+// hotelPacker groups hotel rows joined with tags, keeping the order of the rows.
+type hotelPacker struct {
+	hotels []*models.Hotel
+	hotelsMap map[int64]*models.Hotel
+}
+
+func newHotelPacker() *hotelPacker {
+	return &hotelPacker{
+		hotelsMap: make(map[int64]*models.Hotel),
+	}
+}
+
+func (p *hotelPacker) add(hotel models.Hotel, tagName sql.NullString) *models.Hotel {
+	packed, exists := p.hotelsMap[hotel.Id]
+	if !exists {
+		packed = &hotel
+		p.hotelsMap[hotel.Id] = packed
+		p.hotels = append(p.hotels, packed)
+	}
+	if tagName.Valid {
+		packed.Tags = append(packed.Tags, models.Tag{Name: tagName.String})
+	}
+	return packed
+}
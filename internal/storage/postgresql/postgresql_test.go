@@ -0,0 +1,383 @@
+package postgresql_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/Bitummit/booking_api/internal/storage/postgresql"
+	"github.com/Bitummit/booking_api/internal/storage/postgresql/pgtest"
+	"github.com/Bitummit/booking_api/internal/storage/storagetest"
+)
+
+var (
+	cluster *pgtest.Cluster
+	// unavailable tells why the tests are skipped when no cluster could be started
+	unavailable error
+)
+
+func TestMain(m *testing.M) {
+	var err error
+	cluster, err = pgtest.Start(context.Background())
+	if errors.Is(err, pgtest.ErrorUnavailable) {
+		unavailable = err
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "starting postgres: %v\n", err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	if cluster != nil {
+		if err := cluster.Stop(); err != nil {
+			fmt.Fprintf(os.Stderr, "stopping postgres: %v\n", err)
+		}
+	}
+	os.Exit(code)
+}
+
+func open(t testing.TB) *postgresql.Storage {
+	t.Helper()
+	if cluster == nil {
+		t.Skip(unavailable)
+	}
+	return cluster.Open(t)
+}
+
+func count(t testing.TB, s *postgresql.Storage, table string) int {
+	t.Helper()
+	var n int
+	if err := s.DB.QueryRow(context.Background(), "SELECT count(*) FROM "+table).Scan(&n); err != nil {
+		t.Fatalf("counting %s: %v", table, err)
+	}
+	return n
+}
+
+func TestStorage(t *testing.T) {
+	if cluster == nil {
+		t.Skip(unavailable)
+	}
+	storagetest.Run(t, cluster.Backend)
+}
+
+func TestCreateTag(t *testing.T) {
+	s := open(t)
+	ctx := context.Background()
+
+	for i, name := range []string{"wifi", "pool"} {
+		id, err := s.CreateTag(ctx, models.Tag{Name: name})
+		if err != nil {
+			t.Fatalf("creating tag %s: %v", name, err)
+		}
+		if want := int64(i + 1); id != want {
+			t.Errorf("tag %s: got id %d, want %d", name, id, want)
+		}
+	}
+	if _, err := s.CreateTag(ctx, models.Tag{Name: "wifi"}); !errors.Is(err, postgresql.ErrorExists) {
+		t.Fatalf("duplicate tag: got %v, want ErrorExists", err)
+	}
+	if n := count(t, s, "tag"); n != 2 {
+		t.Fatalf("got %d tags, want 2", n)
+	}
+	// the unique constraint rejected the row, the pool connection is fine
+	if _, err := s.CreateTag(ctx, models.Tag{Name: "spa"}); err != nil {
+		t.Fatalf("creating tag after a duplicate: %v", err)
+	}
+}
+
+func TestCreateCity(t *testing.T) {
+	s := open(t)
+	ctx := context.Background()
+
+	id, err := s.CreateCity(ctx, models.City{Name: "Almaty"})
+	if err != nil {
+		t.Fatalf("creating city: %v", err)
+	}
+	if city, err := s.GetCity(ctx, id); err != nil || city.Name != "Almaty" {
+		t.Fatalf("getting city: got %+v, %v", city, err)
+	}
+	if _, err := s.CreateCity(ctx, models.City{Name: "Almaty"}); !errors.Is(err, postgresql.ErrorExists) {
+		t.Fatalf("duplicate city: got %v, want ErrorExists", err)
+	}
+	if n := count(t, s, "city"); n != 1 {
+		t.Fatalf("got %d cities, want 1", n)
+	}
+	if _, err := s.CreateHotel(ctx, models.Hotel{Name: "Hotel"}, "Astana", nil); !errors.Is(err, postgresql.ErrorCityNotExists) {
+		t.Fatalf("hotel in an unknown city: got %v, want ErrorCityNotExists", err)
+	}
+}
+
+func TestCreateHotelRollback(t *testing.T) {
+	s := open(t)
+	ctx := context.Background()
+	managerID := cluster.AddUser(t, models.User{Username: "manager", Role: models.RoleManager})
+	if _, err := s.CreateCity(ctx, models.City{Name: "Almaty"}); err != nil {
+		t.Fatalf("creating city: %v", err)
+	}
+	if _, err := s.CreateTag(ctx, models.Tag{Name: "wifi"}); err != nil {
+		t.Fatalf("creating tag: %v", err)
+	}
+
+	hotel := models.Hotel{Name: "Hotel", ManagerId: managerID}
+	// wifi is linked before spa turns out to be unknown
+	if _, err := s.CreateHotel(ctx, hotel, "Almaty", []string{"wifi", "spa"}); !errors.Is(err, postgresql.ErrorTagNotExists) {
+		t.Fatalf("unknown tag: got %v, want ErrorTagNotExists", err)
+	}
+	if n := count(t, s, "hotel"); n != 0 {
+		t.Errorf("got %d hotels after the rollback, want 0", n)
+	}
+	if n := count(t, s, "tag_hotel"); n != 0 {
+		t.Errorf("got %d tag links after the rollback, want 0", n)
+	}
+
+	// inside a caller's transaction only the savepoint is rolled back
+	err := s.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := s.CreateHotel(ctx, hotel, "Almaty", []string{"spa"}); !errors.Is(err, postgresql.ErrorTagNotExists) {
+			t.Errorf("unknown tag in a transaction: got %v, want ErrorTagNotExists", err)
+		}
+		_, err := s.CreateHotel(ctx, hotel, "Almaty", []string{"wifi"})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("creating hotel after a failed one: %v", err)
+	}
+	if n := count(t, s, "hotel"); n != 1 {
+		t.Errorf("got %d hotels, want 1", n)
+	}
+	if n := count(t, s, "tag_hotel"); n != 1 {
+		t.Errorf("got %d tag links, want 1", n)
+	}
+}
+
+// seedHotels creates A with three tags and B without tags for the first manager, and C with
+// one tag for the second one.
+func seedHotels(t *testing.T, s *postgresql.Storage) (first, second int64) {
+	t.Helper()
+	ctx := context.Background()
+	first = cluster.AddUser(t, models.User{Username: "first", Role: models.RoleManager})
+	second = cluster.AddUser(t, models.User{Username: "second", Role: models.RoleManager})
+	if _, err := s.CreateCity(ctx, models.City{Name: "Almaty"}); err != nil {
+		t.Fatalf("creating city: %v", err)
+	}
+	for _, name := range []string{"wifi", "pool", "spa"} {
+		if _, err := s.CreateTag(ctx, models.Tag{Name: name}); err != nil {
+			t.Fatalf("creating tag: %v", err)
+		}
+	}
+	for _, hotel := range []struct {
+		name string
+		manager int64
+		tags []string
+	}{
+		{"A", first, []string{"wifi", "pool", "spa"}},
+		{"B", first, nil},
+		{"C", second, []string{"wifi"}},
+	} {
+		if _, err := s.CreateHotel(ctx, models.Hotel{Name: hotel.name, ManagerId: hotel.manager}, "Almaty", hotel.tags); err != nil {
+			t.Fatalf("creating hotel %s: %v", hotel.name, err)
+		}
+	}
+	return first, second
+}
+
+// tagsByHotel maps hotel names to their sorted tag names and checks every hotel appears once.
+func tagsByHotel(t *testing.T, hotels []*models.Hotel) map[string][]string {
+	t.Helper()
+	tags := make(map[string][]string)
+	for _, hotel := range hotels {
+		if _, seen := tags[hotel.Name]; seen {
+			t.Fatalf("hotel %s was returned twice", hotel.Name)
+		}
+		if hotel.City.Name != "Almaty" {
+			t.Errorf("hotel %s: got city %q, want Almaty", hotel.Name, hotel.City.Name)
+		}
+		names := []string{}
+		for _, tag := range hotel.Tags {
+			names = append(names, tag.Name)
+		}
+		slices.Sort(names)
+		tags[hotel.Name] = names
+	}
+	return tags
+}
+
+func TestGetHotelsByManager(t *testing.T) {
+	s := open(t)
+	first, second := seedHotels(t, s)
+	ctx := context.Background()
+
+	hotels, err := s.GetHotelsByManager(ctx, first)
+	if err != nil {
+		t.Fatalf("getting hotels of the first manager: %v", err)
+	}
+	got := tagsByHotel(t, hotels)
+	want := map[string][]string{"A": {"pool", "spa", "wifi"}, "B": {}}
+	if !equalTags(got, want) {
+		t.Errorf("first manager: got %v, want %v", got, want)
+	}
+
+	hotels, err = s.GetHotelsByManager(ctx, second)
+	if err != nil {
+		t.Fatalf("getting hotels of the second manager: %v", err)
+	}
+	if got := tagsByHotel(t, hotels); !equalTags(got, map[string][]string{"C": {"wifi"}}) {
+		t.Errorf("second manager: got %v", got)
+	}
+
+	hotels, err = s.GetHotelsByManager(ctx, second+100)
+	if err != nil || len(hotels) != 0 {
+		t.Errorf("manager without hotels: got %v, %v", hotels, err)
+	}
+}
+
+func TestGetAllHotes(t *testing.T) {
+	s := open(t)
+	seedHotels(t, s)
+
+	// five joined rows are packed into three hotels
+	hotels, err := s.GetAllHotes(context.Background())
+	if err != nil {
+		t.Fatalf("getting hotels: %v", err)
+	}
+	got := tagsByHotel(t, hotels)
+	want := map[string][]string{"A": {"pool", "spa", "wifi"}, "B": {}, "C": {"wifi"}}
+	if !equalTags(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func equalTags(a, b map[string][]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, tags := range a {
+		other, ok := b[name]
+		if !ok || !slices.Equal(tags, other) {
+			return false
+		}
+	}
+	return true
+}
+
+const (
+	insertBookingStmt = `
+		INSERT INTO booking(entry_date, leave_date, price, current_status, guests_count, user_id, room_id)
+		VALUES('2025-03-01', '2025-03-04', 300, 'created', 1, $1, $2);
+	`
+	// the DELETE of the room waits for the row lock the uncommitted booking holds
+	waitingDeleteStmt = `
+		SELECT EXISTS(
+			SELECT 1 FROM pg_stat_activity
+			WHERE wait_event_type='Lock' AND query LIKE 'DELETE FROM room%'
+		);
+	`
+)
+
+// seedRoom creates a room of a new hotel and returns the guest, category and room ids.
+func seedRoom(t *testing.T, s *postgresql.Storage) (guestID, categoryID, roomID int64) {
+	t.Helper()
+	ctx := context.Background()
+	managerID := cluster.AddUser(t, models.User{Username: "manager", Role: models.RoleManager})
+	guestID = cluster.AddUser(t, models.User{Username: "guest"})
+	if _, err := s.CreateCity(ctx, models.City{Name: "Almaty"}); err != nil {
+		t.Fatalf("creating city: %v", err)
+	}
+	hotelID, err := s.CreateHotel(ctx, models.Hotel{Name: "Hotel", ManagerId: managerID}, "Almaty", nil)
+	if err != nil {
+		t.Fatalf("creating hotel: %v", err)
+	}
+	categoryID, err = s.CreateRoomCategory(ctx, models.RoomCategory{HotelId: hotelID, Name: "Standard", Price: 100, Capacity: 2, Size: 20})
+	if err != nil {
+		t.Fatalf("creating category: %v", err)
+	}
+	roomID, err = s.CreateRoom(ctx, hotelID, models.Room{Number: "1", CategoryId: categoryID})
+	if err != nil {
+		t.Fatalf("creating room: %v", err)
+	}
+	return guestID, categoryID, roomID
+}
+
+// deleteRoomInTx deletes the room in a transaction the way the service does, and reads the
+// room afterwards to check the transaction is still usable.
+func deleteRoomInTx(s *postgresql.Storage, categoryID, roomID int64) (bool, error) {
+	var retired bool
+	err := s.WithTx(context.Background(), func(ctx context.Context) error {
+		var err error
+		retired, err = s.DeleteRoom(ctx, categoryID, roomID)
+		if err != nil {
+			return err
+		}
+		_, err = s.GetRoom(ctx, roomID)
+		return err
+	})
+	return retired, err
+}
+
+func TestDeleteRoomWithBookings(t *testing.T) {
+	s := open(t)
+	guestID, categoryID, roomID := seedRoom(t, s)
+	if _, err := s.DB.Exec(context.Background(), insertBookingStmt, guestID, roomID); err != nil {
+		t.Fatalf("booking: %v", err)
+	}
+
+	retired, err := deleteRoomInTx(s, categoryID, roomID)
+	if err != nil || !retired {
+		t.Fatalf("deleting booked room: got retired %v, %v", retired, err)
+	}
+	if room, err := s.GetRoom(context.Background(), roomID); err != nil || room.Active {
+		t.Fatalf("retired room: got %+v, %v", room, err)
+	}
+}
+
+func TestDeleteRoomBookedConcurrently(t *testing.T) {
+	s := open(t)
+	guestID, categoryID, roomID := seedRoom(t, s)
+	ctx := context.Background()
+
+	// the booking is not committed yet when DeleteRoom checks for bookings
+	booking, err := s.DB.Begin(ctx)
+	if err != nil {
+		t.Fatalf("beginning booking: %v", err)
+	}
+	defer booking.Rollback(ctx)
+	if _, err := booking.Exec(ctx, insertBookingStmt, guestID, roomID); err != nil {
+		t.Fatalf("booking: %v", err)
+	}
+
+	type result struct {
+		retired bool
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		retired, err := deleteRoomInTx(s, categoryID, roomID)
+		done <- result{retired, err}
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for waiting := false; !waiting; {
+		if time.Now().After(deadline) {
+			t.Fatal("DELETE of the room never waited for the booking")
+		}
+		if err := s.DB.QueryRow(ctx, waitingDeleteStmt).Scan(&waiting); err != nil {
+			t.Fatalf("checking locks: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// the DELETE now fails with a foreign key violation inside the caller's transaction
+	if err := booking.Commit(ctx); err != nil {
+		t.Fatalf("committing booking: %v", err)
+	}
+
+	res := <-done
+	if res.err != nil || !res.retired {
+		t.Fatalf("deleting room booked concurrently: got retired %v, %v", res.retired, res.err)
+	}
+	if room, err := s.GetRoom(ctx, roomID); err != nil || room.Active {
+		t.Fatalf("retired room: got %+v, %v", room, err)
+	}
+}
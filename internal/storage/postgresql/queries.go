@@ -4,33 +4,185 @@ const (
 	CreateTagStmt = "INSERT INTO tag(name) VALUES(@name) RETURNING id;"
 	GetTagByName = "SELECT id FROM tag WHERE name=@name;"
 	ListTagsStmt = "SELECT id, name from tag;"
+	GetTagStmt = "SELECT id, name FROM tag WHERE id=@id;"
 	GetMultipleTagsStmt = "SELECT id FROM tag WHERE name=ANY(@tag_array)"
 	DeleteTagStmt = "DELETE FROM tag WHERE id=@id"
 	
 	CreateCityStmt = "INSERT INTO city(name) VALUES(@name) RETURNING id;"
 	GetCityByName = "SELECT id FROM city WHERE name=@name;"
 	ListCitiesStmt = "SELECT id, name from city;"
+	GetCityStmt = "SELECT id, name FROM city WHERE id=@id;"
 	DeleteCityStmt = "DELETE FROM city WHERE id=@id"
 
-	CreateTagHotelStmt = "INSERT INTO tag_hotel(hotel_id, tag_id) VALUES(@hotel_id, (SELECT id FROM tag WHERE name=@tag_name LIMIT 1));"
-	CheckHotelNameUniqueStmt = "SELECT id FROM hotel WHERE name=@name"
-	CreateHotelStmt = "INSERT INTO hotel(name, description, city_id) VALUES(@name, @desc, (SELECT id FROM city WHERE name=@city_name LIMIT 1)) RETURNING id;"
+	CreateTagHotelStmt = `
+		INSERT INTO tag_hotel(hotel_id, tag_id) VALUES(@hotel_id, (SELECT id FROM tag WHERE name=@tag_name))
+		ON CONFLICT (hotel_id, tag_id) DO NOTHING;
+	`
+	CreateHotelStmt = "INSERT INTO hotel(name, description, city_id, manager_id) VALUES(@name, @desc, (SELECT id FROM city WHERE name=@city_name), @manager_id) RETURNING id;"
 	GetOwnedHotelsStmt = `
-		SELECT h.id, h.name, h.description, c.name, t.name 
+		SELECT h.id, h.name, h.description, h.active, h.created_at, c.name, t.name 
 		FROM hotel AS h 
 		LEFT JOIN city AS c ON h.city_id=c.id 
 		LEFT JOIN tag_hotel AS th ON th.hotel_id=h.id 
 		LEFT JOIN tag AS t ON th.tag_id=t.id 
-		WHERE h.manager_id=@user_id;
+		WHERE h.manager_id=@user_id AND h.active;
 	`
 	GetAllHotelsStmt = `
-		SELECT h.id, h.name, h.description, c.name, t.name 
+		SELECT h.id, h.name, h.description, h.active, h.created_at, c.name, t.name 
 		FROM hotel AS h 
 		LEFT JOIN city AS c ON h.city_id=c.id 
 		LEFT JOIN tag_hotel AS th ON th.hotel_id=h.id
-		LEFT JOIN tag AS t ON th.tag_id=t.id;
+		LEFT JOIN tag AS t ON th.tag_id=t.id
+		WHERE h.active;
 	`
 	GetHotelStmt = `
-		SELECT h.id, h.name, h.description, c.name, t.name
+		SELECT h.id, h.name, h.description, h.active, h.created_at, c.name, t.name
+		FROM hotel AS h
+		LEFT JOIN city AS c ON h.city_id=c.id
+		LEFT JOIN tag_hotel AS th ON th.hotel_id=h.id
+		LEFT JOIN tag AS t ON th.tag_id=t.id
+		WHERE h.id=@id AND h.active;
+	`
+	UpdateHotelStmt = `
+		UPDATE hotel
+		SET name=COALESCE(@name, name),
+			description=COALESCE(@desc, description),
+			city_id=COALESCE((SELECT id FROM city WHERE name=@city_name), city_id)
+		WHERE id=@id AND active;
+	`
+	ListHotelTagNamesStmt = "SELECT t.name FROM tag_hotel AS th JOIN tag AS t ON th.tag_id=t.id WHERE th.hotel_id=@hotel_id;"
+	DeleteTagHotelStmt = "DELETE FROM tag_hotel WHERE hotel_id=@hotel_id AND tag_id=(SELECT id FROM tag WHERE name=@tag_name);"
+	SoftDeleteHotelStmt = "UPDATE hotel SET active=FALSE WHERE id=@id AND active;"
+	GetHotelManagerStmt = "SELECT manager_id FROM hotel WHERE id=@id AND active;"
+	LockHotelManagerStmt = "SELECT manager_id FROM hotel WHERE id=@hotel_id FOR UPDATE;"
+	// the target keeps its role until the transfer is committed
+	LockManagerRoleStmt = "SELECT role FROM my_user WHERE id=@to_manager_id FOR SHARE;"
+	SetHotelManagerStmt = "UPDATE hotel SET manager_id=@to_manager_id WHERE id=@hotel_id;"
+	CreateHotelTransferStmt = `
+		INSERT INTO hotel_transfer(hotel_id, from_manager_id, to_manager_id, actor_id)
+		VALUES(@hotel_id, @from_manager_id, @to_manager_id, @actor_id)
+		RETURNING id, created_at;
+	`
+	ListHotelTransfersStmt = `
+		SELECT id, hotel_id, from_manager_id, to_manager_id, actor_id, created_at
+		FROM hotel_transfer WHERE hotel_id=@hotel_id ORDER BY created_at, id;
+	`
+
+	CreateRoomCategoryStmt = `
+		INSERT INTO room_category(name, price, capacity, description, size, hotel_id)
+		VALUES(@name, @price, @capacity, @desc, @size, @hotel_id) RETURNING id;
+	`
+	ListRoomCategoriesStmt = `
+		SELECT id, name, price, capacity, description, size, hotel_id
+		FROM room_category WHERE hotel_id=@hotel_id ORDER BY id;
+	`
+	GetRoomCategoryStmt = `
+		SELECT id, name, price, capacity, description, size, hotel_id
+		FROM room_category WHERE id=@id;
+	`
+	UpdateRoomCategoryStmt = `
+		UPDATE room_category
+		SET name=@name, price=@price, capacity=@capacity, description=@desc, size=@size
+		WHERE id=@id AND hotel_id=@hotel_id;
+	`
+	DeleteRoomCategoryStmt = "DELETE FROM room_category WHERE id=@id AND hotel_id=@hotel_id;"
+
+	LockHotelRoomsStmt = "SELECT pg_advisory_xact_lock(@hotel_id);"
+	CheckRoomNumberUniqueStmt = `
+		SELECT r.id FROM room AS r
+		JOIN room_category AS rc ON r.category_id=rc.id
+		WHERE rc.hotel_id=@hotel_id AND r.number=@number;
+	`
+	CreateRoomStmt = "INSERT INTO room(number, category_id) VALUES(@number, @category_id) RETURNING id;"
+	ListRoomsStmt = "SELECT id, number, category_id, active FROM room WHERE category_id=@category_id ORDER BY length(number), number;"
+	GetRoomStmt = "SELECT id, number, category_id, active FROM room WHERE id=@id;"
+	CheckRoomHasBookingsStmt = "SELECT EXISTS(SELECT 1 FROM booking WHERE room_id=@id);"
+	RetireRoomStmt = "UPDATE room SET active=FALSE WHERE id=@id AND category_id=@category_id;"
+	DeleteRoomStmt = "DELETE FROM room WHERE id=@id AND category_id=@category_id;"
+
+	FindFreeRoomStmt = `
+		SELECT r.id FROM room AS r
+		WHERE r.category_id=@category_id AND r.active
+		AND NOT EXISTS (
+			SELECT 1 FROM booking AS b
+			WHERE b.room_id=r.id
+			AND b.current_status NOT IN ('cancelled', 'no_show')
+			AND daterange(b.entry_date, b.leave_date) && daterange(@entry_date, @leave_date)
+		)
+		ORDER BY r.id LIMIT 1;
+	`
+	CreateBookingStmt = `
+		INSERT INTO booking(entry_date, leave_date, price, current_status, guests_count, user_id, room_id)
+		VALUES(@entry_date, @leave_date, @price, CAST(@status AS status_enum), @guests_count, @user_id, @room_id)
+		RETURNING id;
+	`
+	// %s is replaced with the hotel condition built from fixed strings
+	SearchAvailabilityStmt = `
+		SELECT h.id, h.name, h.description, h.active, h.created_at, c.name,
+			rc.id, rc.name, rc.price, rc.capacity, rc.description, rc.size, COUNT(r.id)
+		FROM hotel AS h
+		JOIN city AS c ON h.city_id=c.id
+		JOIN room_category AS rc ON rc.hotel_id=h.id
+		JOIN room AS r ON r.category_id=rc.id AND r.active
+		WHERE %s AND h.active AND rc.capacity>=@guests
+		AND NOT EXISTS (
+			SELECT 1 FROM booking AS b
+			WHERE b.room_id=r.id
+			AND b.current_status NOT IN ('cancelled', 'no_show')
+			AND daterange(b.entry_date, b.leave_date) && daterange(@from, @to)
+		)
+		GROUP BY h.id, c.name, rc.id
+		ORDER BY h.id, rc.price, rc.id;
+	`
+	GetBookingStmt = `
+		SELECT b.id, b.entry_date, b.leave_date, b.price, b.current_status::text, b.guests_count,
+			b.user_id, b.room_id, rc.hotel_id
+		FROM booking AS b
+		JOIN room AS r ON b.room_id=r.id
+		JOIN room_category AS rc ON r.category_id=rc.id
+		WHERE b.id=@id;
+	`
+	UpdateBookingStatusStmt = `
+		UPDATE booking SET current_status=CAST(@to AS status_enum)
+		WHERE id=@id AND current_status=CAST(@from AS status_enum);
+	`
+
+	ListUsersStmt = `
+		SELECT id, username, first_name, last_name, email, birthday, role, blocked
+		FROM my_user
+		WHERE (username ILIKE @query OR email ILIKE @query OR first_name || ' ' || last_name ILIKE @query)
+		AND (@role = '' OR role=@role)
+		AND (CAST(@blocked AS BOOLEAN) IS NULL OR blocked=@blocked)
+		ORDER BY id
+		LIMIT @limit OFFSET @offset;
+	`
+	GetUserStmt = `
+		SELECT id, username, first_name, last_name, email, birthday, role, blocked
+		FROM my_user WHERE id=@id;
+	`
+	GetUserByUsernameStmt = `
+		SELECT id, username, first_name, last_name, email, birthday, role, blocked
+		FROM my_user WHERE username=@username;
+	`
+	IsUserBlockedStmt = "SELECT blocked FROM my_user WHERE id=@id;"
+	SetUserBlockedStmt = "UPDATE my_user SET blocked=@blocked WHERE id=@id;"
+	UpdateUserProfileStmt = `
+		UPDATE my_user SET first_name=@first_name, last_name=@last_name, email=@email, birthday=@birthday
+		WHERE id=@id;
+	`
+	CreateAuditEntryStmt = `
+		INSERT INTO audit_log(actor_id, action, entity_type, entity_id, before, after)
+		VALUES(@actor_id, @action, @entity_type, @entity_id, CAST(@before AS JSONB), CAST(@after AS JSONB));
+	`
+	ListAuditStmt = `
+		SELECT id, actor_id, action, entity_type, entity_id, before, after, created_at
+		FROM audit_log
+		WHERE (@actor_id = 0 OR actor_id=@actor_id)
+		AND (@entity_type = '' OR entity_type=@entity_type)
+		AND (@entity_id = 0 OR entity_id=@entity_id)
+		AND (CAST(@from AS TIMESTAMPTZ) IS NULL OR created_at>=@from)
+		AND (CAST(@to AS TIMESTAMPTZ) IS NULL OR created_at<@to)
+		ORDER BY created_at DESC, id DESC
+		LIMIT @limit OFFSET @offset;
 	`
-)
\ No newline at end of file
+)
@@ -0,0 +1,127 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func (s *Storage) CreateRoom(ctx context.Context, hotelID int64, room models.Room) (int64, error) {
+	var id int64
+	args := pgx.NamedArgs{
+		"hotel_id": hotelID,
+		"number": room.Number,
+		"category_id": room.CategoryId,
+	}
+
+	// room numbers are unique per hotel, so concurrent inserts into one hotel are serialized
+	err := s.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := s.db(ctx).Exec(ctx, LockHotelRoomsStmt, args); err != nil {
+			return fmt.Errorf("database internal error: %w", err)
+		}
+
+		err := s.db(ctx).QueryRow(ctx, CheckRoomNumberUniqueStmt, args).Scan(&id)
+		if err == nil {
+			return fmt.Errorf("database error: %w", ErrorExists)
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("database error: %w", err)
+		}
+
+		err = s.db(ctx).QueryRow(ctx, CreateRoomStmt, args).Scan(&id)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("database error: %w", ErrorInsertion)
+			}
+			return fmt.Errorf("database error: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *Storage) ListRooms(ctx context.Context, categoryID int64) ([]models.Room, error) {
+	rooms := []models.Room{}
+	args := pgx.NamedArgs{
+		"category_id": categoryID,
+	}
+
+	rows, err := s.db(ctx).Query(ctx, ListRoomsStmt, args)
+	if err != nil {
+		return nil, fmt.Errorf("fetching data: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var room models.Room
+		err = rows.Scan(&room.Id, &room.Number, &room.CategoryId, &room.Active)
+		if err != nil {
+			return nil, fmt.Errorf("fetching data: %w", err)
+		}
+		rooms = append(rooms, room)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fetching data: %w", err)
+	}
+
+	return rooms, nil
+}
+
+func (s *Storage) GetRoom(ctx context.Context, id int64) (*models.Room, error) {
+	var room models.Room
+	err := s.db(ctx).QueryRow(ctx, GetRoomStmt, pgx.NamedArgs{"id": id}).Scan(&room.Id, &room.Number, &room.CategoryId, &room.Active)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("database error: %w", ErrorNotExists)
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return &room, nil
+}
+
+// DeleteRoom removes a room that was never booked. Rooms with bookings are only
+// retired, so the booking history keeps pointing at an existing room.
+func (s *Storage) DeleteRoom(ctx context.Context, categoryID, id int64) (bool, error) {
+	var hasBookings bool
+	args := pgx.NamedArgs{
+		"id": id,
+		"category_id": categoryID,
+	}
+
+	err := s.db(ctx).QueryRow(ctx, CheckRoomHasBookingsStmt, args).Scan(&hasBookings)
+	if err != nil {
+		return false, fmt.Errorf("database error: %w", err)
+	}
+
+	stmt := DeleteRoomStmt
+	if hasBookings {
+		stmt = RetireRoomStmt
+	}
+	var resp pgconn.CommandTag
+	// inside a caller's transaction the savepoint keeps it usable after the foreign key violation
+	err = s.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = s.db(ctx).Exec(ctx, stmt, args)
+		return err
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		// booked after the check above
+		hasBookings = true
+		resp, err = s.db(ctx).Exec(ctx, RetireRoomStmt, args)
+	}
+	if err != nil {
+		return false, fmt.Errorf("deleting: %w", err)
+	}
+	if resp.RowsAffected() == 0 {
+		return false, fmt.Errorf("deleting: %w", ErrorNotExists)
+	}
+
+	return hasBookings, nil
+}
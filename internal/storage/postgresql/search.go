@@ -0,0 +1,172 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+type sortKey struct {
+	expr string
+	cast string
+}
+
+// hotelSortKeys is the only source of sql put into ORDER BY, user input never reaches the query text.
+var hotelSortKeys = map[string]sortKey{
+	"id": {expr: "h.id", cast: "bigint"},
+	"name": {expr: "h.name", cast: "text"},
+	"price": {expr: "COALESCE(p.min_price, 'Infinity'::float8)", cast: "float8"},
+}
+
+func (s *Storage) SearchHotels(ctx context.Context, filter models.HotelFilter) ([]*models.Hotel, *models.HotelCursor, error) {
+	stmt, args, err := buildHotelSearch(filter)
+	if err != nil {
+		return nil, nil, fmt.Errorf("building query: %w", err)
+	}
+
+	rows, err := s.db(ctx).Query(ctx, stmt, args)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetching data: %w", err)
+	}
+	defer rows.Close()
+
+	packer := newHotelPacker()
+	keys := make(map[int64]string)
+	for rows.Next() {
+		var hotel models.Hotel
+		var tagName sql.NullString
+		var hotelDesc sql.NullString
+		var key string
+
+		err := rows.Scan(&hotel.Id, &hotel.Name, &hotelDesc, &hotel.Active, &hotel.CreatedAt, &hotel.City.Name, &tagName, &key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("scanning row: %w", err)
+		}
+		hotel.Desc = hotelDesc.String
+
+		packer.add(hotel, tagName)
+		keys[hotel.Id] = key
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("scanning row: %w", err)
+	}
+
+	// one extra hotel is fetched to know if there is a next page
+	hotels := packer.hotels
+	if len(hotels) <= filter.Limit {
+		return hotels, nil, nil
+	}
+	hotels = hotels[:filter.Limit]
+	last := hotels[len(hotels)-1]
+	cursor := &models.HotelCursor{
+		Sort: filter.Sort,
+		Value: keys[last.Id],
+		Id: last.Id,
+	}
+
+	return hotels, cursor, nil
+}
+
+func buildHotelSearch(filter models.HotelFilter) (string, pgx.NamedArgs, error) {
+	key, ok := hotelSortKeys[filter.Sort]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown sort %q", filter.Sort)
+	}
+	direction, compare := "ASC", ">"
+	if filter.Desc {
+		direction, compare = "DESC", "<"
+	}
+
+	conds := []string{"h.active"}
+	args := pgx.NamedArgs{
+		"limit": filter.Limit + 1,
+	}
+
+	if filter.City != "" {
+		conds = append(conds, "c.name=@city")
+		args["city"] = filter.City
+	}
+	if filter.Name != "" {
+		conds = append(conds, "h.name ILIKE @name")
+		args["name"] = "%" + escapeLike(filter.Name) + "%"
+	}
+	if len(filter.Tags) > 0 {
+		tags := slices.Compact(slices.Sorted(slices.Values(filter.Tags)))
+		args["tags"] = tags
+		if filter.AllTags {
+			conds = append(conds, `(
+				SELECT COUNT(DISTINCT t.id) FROM tag_hotel AS th JOIN tag AS t ON th.tag_id=t.id
+				WHERE th.hotel_id=h.id AND t.name=ANY(@tags)
+			)=@tags_count`)
+			args["tags_count"] = len(tags)
+		} else {
+			conds = append(conds, `EXISTS (
+				SELECT 1 FROM tag_hotel AS th JOIN tag AS t ON th.tag_id=t.id
+				WHERE th.hotel_id=h.id AND t.name=ANY(@tags)
+			)`)
+		}
+	}
+
+	// price and capacity must be satisfied by the same room category
+	var categoryConds []string
+	if filter.PriceFrom > 0 {
+		categoryConds = append(categoryConds, "rc.price>=@price_from")
+		args["price_from"] = filter.PriceFrom
+	}
+	if filter.PriceTo > 0 {
+		categoryConds = append(categoryConds, "rc.price<=@price_to")
+		args["price_to"] = filter.PriceTo
+	}
+	if filter.Capacity > 0 {
+		categoryConds = append(categoryConds, "rc.capacity>=@capacity")
+		args["capacity"] = filter.Capacity
+	}
+	if len(categoryConds) > 0 {
+		conds = append(conds, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM room_category AS rc WHERE rc.hotel_id=h.id AND %s)",
+			strings.Join(categoryConds, " AND "),
+		))
+	}
+
+	if filter.Cursor != nil {
+		conds = append(conds, fmt.Sprintf(
+			"(%s, h.id) %s (CAST(@cursor_value AS %s), @cursor_id)", key.expr, compare, key.cast,
+		))
+		args["cursor_value"] = filter.Cursor.Value
+		args["cursor_id"] = filter.Cursor.Id
+	}
+
+	where := "WHERE " + strings.Join(conds, " AND ")
+
+	stmt := fmt.Sprintf(`
+		WITH page AS (
+			SELECT h.id, %[1]s AS sort_key
+			FROM hotel AS h
+			JOIN city AS c ON h.city_id=c.id
+			LEFT JOIN LATERAL (
+				SELECT MIN(price)::float8 AS min_price FROM room_category WHERE hotel_id=h.id
+			) AS p ON TRUE
+			%[2]s
+			ORDER BY sort_key %[3]s, h.id %[3]s
+			LIMIT @limit
+		)
+		SELECT h.id, h.name, h.description, h.active, h.created_at, c.name, t.name, page.sort_key::text
+		FROM page
+		JOIN hotel AS h ON page.id=h.id
+		JOIN city AS c ON h.city_id=c.id
+		LEFT JOIN tag_hotel AS th ON th.hotel_id=h.id
+		LEFT JOIN tag AS t ON th.tag_id=t.id
+		ORDER BY page.sort_key %[3]s, page.id %[3]s, t.name;
+	`, key.expr, where, direction)
+
+	return stmt, args, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,97 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// TransferHotel changes the hotel manager and records the transfer in one transaction.
+func (s *Storage) TransferHotel(ctx context.Context, transfer models.HotelTransfer) (*models.HotelTransfer, error) {
+	var fromManagerID sql.NullInt64
+
+	err := s.WithTx(ctx, func(ctx context.Context) error {
+		args := pgx.NamedArgs{
+			"hotel_id": transfer.HotelId,
+			"to_manager_id": transfer.ToManagerId,
+			"actor_id": transfer.ActorId,
+		}
+		err := s.db(ctx).QueryRow(ctx, LockHotelManagerStmt, args).Scan(&fromManagerID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("database error: %w", ErrorNotExists)
+			}
+			return fmt.Errorf("database error: %w", err)
+		}
+		args["from_manager_id"] = fromManagerID
+
+		var role models.Role
+		err = s.db(ctx).QueryRow(ctx, LockManagerRoleStmt, args).Scan(&role)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("database error: %w", ErrorUserNotExists)
+			}
+			return fmt.Errorf("database error: %w", err)
+		}
+		if role != models.RoleManager && role != models.RoleAdmin {
+			return fmt.Errorf("transferring: %w", ErrorNotManager)
+		}
+
+		if _, err = s.db(ctx).Exec(ctx, SetHotelManagerStmt, args); err != nil {
+			return fmt.Errorf("database error: %w", err)
+		}
+
+		err = s.db(ctx).QueryRow(ctx, CreateHotelTransferStmt, args).Scan(&transfer.Id, &transfer.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("database error: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	transfer.FromManagerId = fromManagerID.Int64
+	return &transfer, nil
+}
+
+func (s *Storage) ListHotelTransfers(ctx context.Context, hotelID int64) ([]models.HotelTransfer, error) {
+	transfers := []models.HotelTransfer{}
+	args := pgx.NamedArgs{
+		"hotel_id": hotelID,
+	}
+
+	rows, err := s.db(ctx).Query(ctx, ListHotelTransfersStmt, args)
+	if err != nil {
+		return nil, fmt.Errorf("fetching data: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var transfer models.HotelTransfer
+		var fromManagerID, actorID sql.NullInt64
+		err = rows.Scan(
+			&transfer.Id,
+			&transfer.HotelId,
+			&fromManagerID,
+			&transfer.ToManagerId,
+			&actorID,
+			&transfer.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("fetching data: %w", err)
+		}
+		transfer.FromManagerId = fromManagerID.Int64
+		transfer.ActorId = actorID.Int64
+
+		transfers = append(transfers, transfer)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fetching data: %w", err)
+	}
+
+	return transfers, nil
+}
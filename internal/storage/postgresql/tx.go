@@ -0,0 +1,78 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	serializationFailure = "40001"
+	deadlockDetected = "40P01"
+	maxTxAttempts = 3
+)
+
+type txKey struct{}
+
+// querier is the part of the pool and of pgx.Tx the storage methods use.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// db returns the transaction carried by ctx, or the pool when there is none.
+func (s *Storage) db(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return s.DB
+}
+
+// WithTx runs fn in a transaction, storage methods called with the ctx passed to fn join it.
+// It is committed when fn returns nil and rolled back otherwise. Called inside another
+// transaction WithTx only sets a savepoint, retrying is left to the outermost call.
+//
+// Transactions run at the default READ COMMITTED level, concurrent writers are kept apart by
+// row locks, advisory locks and the booking exclusion constraint. fn runs again after a
+// deadlock between those locks or a serialization failure, which a stricter isolation level
+// would add. fn must not have effects outside the database that can not be repeated.
+func (s *Storage) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return runTx(ctx, tx.Begin, fn)
+	}
+
+	var err error
+	for attempt := 0; attempt < maxTxAttempts; attempt++ {
+		err = runTx(ctx, s.DB.Begin, fn)
+		if !isRetryable(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func runTx(ctx context.Context, begin func(context.Context) (pgx.Tx, error), fn func(ctx context.Context) error) error {
+	tx, err := begin(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	// a no-op once the transaction is committed
+	defer tx.Rollback(ctx)
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+	return nil
+}
+
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == serializationFailure || pgErr.Code == deadlockDetected)
+}
@@ -0,0 +1,32 @@
+package postgresql
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestIsRetryable(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		err error
+		want bool
+	}{
+		{"deadlock", &pgconn.PgError{Code: deadlockDetected}, true},
+		{"wrapped deadlock", fmt.Errorf("database error: %w", &pgconn.PgError{Code: deadlockDetected}), true},
+		{"serialization failure", &pgconn.PgError{Code: serializationFailure}, true},
+		{"wrapped serialization failure", fmt.Errorf("committing transaction: %w", &pgconn.PgError{Code: serializationFailure}), true},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"exclusion violation", &pgconn.PgError{Code: exclusionViolation}, false},
+		{"other error", errors.New("connection reset"), false},
+		{"no error", nil, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.err); got != tt.want {
+				t.Errorf("isRetryable = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
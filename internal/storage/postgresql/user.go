@@ -0,0 +1,130 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Storage) ListUsers(ctx context.Context, filter models.UserFilter) ([]models.User, error) {
+	users := []models.User{}
+	args := pgx.NamedArgs{
+		"query": "%" + escapeLike(filter.Query) + "%",
+		"role": string(filter.Role),
+		"blocked": filter.Blocked,
+		"limit": filter.Limit,
+		"offset": filter.Offset,
+	}
+
+	rows, err := s.db(ctx).Query(ctx, ListUsersStmt, args)
+	if err != nil {
+		return nil, fmt.Errorf("fetching data: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("fetching data: %w", err)
+		}
+		users = append(users, *user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fetching data: %w", err)
+	}
+
+	return users, nil
+}
+
+func (s *Storage) GetUser(ctx context.Context, id int64) (*models.User, error) {
+	return s.getUser(ctx, GetUserStmt, pgx.NamedArgs{"id": id})
+}
+
+func (s *Storage) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	return s.getUser(ctx, GetUserByUsernameStmt, pgx.NamedArgs{"username": username})
+}
+
+func (s *Storage) getUser(ctx context.Context, stmt string, args pgx.NamedArgs) (*models.User, error) {
+	user, err := scanUser(s.db(ctx).QueryRow(ctx, stmt, args))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("database error: %w", ErrorUserNotExists)
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return user, nil
+}
+
+// IsUserBlocked treats users missing from my_user as not blocked.
+func (s *Storage) IsUserBlocked(ctx context.Context, id int64) (bool, error) {
+	var blocked bool
+	err := s.db(ctx).QueryRow(ctx, IsUserBlockedStmt, pgx.NamedArgs{"id": id}).Scan(&blocked)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("database error: %w", err)
+	}
+	return blocked, nil
+}
+
+func (s *Storage) SetUserBlocked(ctx context.Context, id int64, blocked bool) error {
+	args := pgx.NamedArgs{
+		"id": id,
+		"blocked": blocked,
+	}
+	resp, err := s.db(ctx).Exec(ctx, SetUserBlockedStmt, args)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	if resp.RowsAffected() == 0 {
+		return fmt.Errorf("database error: %w", ErrorUserNotExists)
+	}
+	return nil
+}
+
+// UpdateUserProfile stores the profile fields of the user, a zero Birthday is stored as NULL.
+func (s *Storage) UpdateUserProfile(ctx context.Context, user models.User) error {
+	args := pgx.NamedArgs{
+		"id": user.Id,
+		"first_name": user.FirstName,
+		"last_name": user.LastName,
+		"email": user.Email,
+		"birthday": sql.NullTime{Time: user.Birthday, Valid: !user.Birthday.IsZero()},
+	}
+	resp, err := s.db(ctx).Exec(ctx, UpdateUserProfileStmt, args)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	if resp.RowsAffected() == 0 {
+		return fmt.Errorf("database error: %w", ErrorUserNotExists)
+	}
+	return nil
+}
+
+// scanUser leaves Birthday zero for users registered without one.
+func scanUser(row pgx.Row) (*models.User, error) {
+	var user models.User
+	var role string
+	var birthday sql.NullTime
+	err := row.Scan(
+		&user.Id,
+		&user.Username,
+		&user.FirstName,
+		&user.LastName,
+		&user.Email,
+		&birthday,
+		&role,
+		&user.Blocked,
+	)
+	if err != nil {
+		return nil, err
+	}
+	user.Role = models.Role(role)
+	user.Birthday = birthday.Time
+	return &user, nil
+}
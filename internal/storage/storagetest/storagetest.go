@@ -0,0 +1,960 @@
+// Package storagetest is the contract every HotelStorage implementation has to meet. Backend
+// packages call Run from their tests, so the postgresql and memory storages are checked
+// against the same cases.
+package storagetest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Bitummit/booking_api/internal/models"
+	"github.com/Bitummit/booking_api/internal/service"
+	"github.com/Bitummit/booking_api/internal/storage/postgresql"
+)
+
+// Backend is an empty storage under test.
+type Backend struct {
+	Storage service.HotelStorage
+	// AddUser stores a user the way the auth service would, HotelStorage cannot create users
+	AddUser func(t *testing.T, user models.User) int64
+}
+
+// Run runs the contract suite, open must return an empty storage for every subtest.
+func Run(t *testing.T, open func(t *testing.T) Backend) {
+	cases := []struct {
+		name string
+		test func(t *testing.T, b Backend)
+	}{
+		{"TagsAndCities", testTagsAndCities},
+		{"CreateHotel", testCreateHotel},
+		{"CreateHotelUnknownTag", testCreateHotelUnknownTag},
+		{"ManagerFiltering", testManagerFiltering},
+		{"UpdateHotel", testUpdateHotel},
+		{"DeleteHotel", testDeleteHotel},
+		{"TransferHotel", testTransferHotel},
+		{"RoomCategories", testRoomCategories},
+		{"Rooms", testRooms},
+		{"Bookings", testBookings},
+		{"SearchHotels", testSearchHotels},
+		{"SearchAvailability", testSearchAvailability},
+		{"Users", testUsers},
+		{"Audit", testAudit},
+		{"WithTx", testWithTx},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.test(t, open(t))
+		})
+	}
+}
+
+func testTagsAndCities(t *testing.T, b Backend) {
+	ctx := context.Background()
+	s := b.Storage
+
+	tagID := createTag(t, s, "wifi")
+	createTag(t, s, "pool")
+	if _, err := s.CreateTag(ctx, models.Tag{Name: "wifi"}); !errors.Is(err, postgresql.ErrorExists) {
+		t.Fatalf("duplicate tag: got %v, want ErrorExists", err)
+	}
+	if tag, err := s.GetTag(ctx, tagID); err != nil || tag.Name != "wifi" {
+		t.Fatalf("getting tag: got %+v, %v", tag, err)
+	}
+	if _, err := s.GetTag(ctx, tagID+100); !errors.Is(err, postgresql.ErrorNotExists) {
+		t.Fatalf("getting unknown tag: got %v, want ErrorNotExists", err)
+	}
+	tags, err := s.ListTags(ctx)
+	if err != nil {
+		t.Fatalf("listing tags: %v", err)
+	}
+	if got := tagNames(tags); !sameNames(got, []string{"wifi", "pool"}) {
+		t.Fatalf("tags: got %v", got)
+	}
+
+	createCity(t, s, "Almaty")
+	usedCityID := createCity(t, s, "Astana")
+	if _, err := s.CreateCity(ctx, models.City{Name: "Almaty"}); !errors.Is(err, postgresql.ErrorExists) {
+		t.Fatalf("duplicate city: got %v, want ErrorExists", err)
+	}
+	if city, err := s.GetCity(ctx, usedCityID); err != nil || city.Name != "Astana" {
+		t.Fatalf("getting city: got %+v, %v", city, err)
+	}
+	if _, err := s.GetCity(ctx, usedCityID+100); !errors.Is(err, postgresql.ErrorNotExists) {
+		t.Fatalf("getting unknown city: got %v, want ErrorNotExists", err)
+	}
+	createHotel(t, s, b.AddUser(t, manager("m1")), "Hotel", "Astana", "wifi")
+
+	if err := s.DeleteCity(ctx, usedCityID); !errors.Is(err, postgresql.ErrorInUse) {
+		t.Fatalf("deleting city with hotels: got %v, want ErrorInUse", err)
+	}
+	cities, err := s.ListCities(ctx)
+	if err != nil {
+		t.Fatalf("listing cities: %v", err)
+	}
+	for _, city := range cities {
+		if city.Name == "Almaty" {
+			if err := s.DeleteCity(ctx, city.Id); err != nil {
+				t.Fatalf("deleting city: %v", err)
+			}
+		}
+	}
+	if err := s.DeleteCity(ctx, usedCityID+100); !errors.Is(err, postgresql.ErrorNotExists) {
+		t.Fatalf("deleting unknown city: got %v, want ErrorNotExists", err)
+	}
+
+	if err := s.DeleteTag(ctx, tagID); err != nil {
+		t.Fatalf("deleting tag in use: %v", err)
+	}
+	if err := s.DeleteTag(ctx, tagID); !errors.Is(err, postgresql.ErrorNotExists) {
+		t.Fatalf("deleting tag twice: got %v, want ErrorNotExists", err)
+	}
+	hotels := allHotels(t, s)
+	if len(hotels) != 1 || len(hotels[0].Tags) != 0 {
+		t.Fatalf("deleted tag is still linked: %+v", hotels)
+	}
+}
+
+func testCreateHotel(t *testing.T, b Backend) {
+	ctx := context.Background()
+	s := b.Storage
+	managerID := b.AddUser(t, manager("m1"))
+	createCity(t, s, "Almaty")
+	createTag(t, s, "wifi")
+	createTag(t, s, "pool")
+
+	hotel := models.Hotel{Name: "Hotel", Desc: "by the lake", ManagerId: managerID}
+	if _, err := s.CreateHotel(ctx, hotel, "Atlantis", nil); !errors.Is(err, postgresql.ErrorCityNotExists) {
+		t.Fatalf("unknown city: got %v, want ErrorCityNotExists", err)
+	}
+
+	id := createHotel(t, s, managerID, "Hotel", "Almaty", "wifi", "pool")
+	got, err := s.GetHotel(ctx, id)
+	if err != nil {
+		t.Fatalf("getting hotel: %v", err)
+	}
+	if got.Id != id || got.Name != "Hotel" || got.City.Name != "Almaty" || !got.Active {
+		t.Fatalf("hotel: got %+v", got)
+	}
+	if names := tagNames(got.Tags); !sameNames(names, []string{"wifi", "pool"}) {
+		t.Fatalf("hotel tags: got %v", names)
+	}
+
+	if _, err := s.CreateHotel(ctx, hotel, "Almaty", nil); !errors.Is(err, postgresql.ErrorExists) {
+		t.Fatalf("duplicate hotel name: got %v, want ErrorExists", err)
+	}
+	if _, err := s.GetHotel(ctx, id+100); !errors.Is(err, postgresql.ErrorNotExists) {
+		t.Fatalf("unknown hotel: got %v, want ErrorNotExists", err)
+	}
+	if got, err := s.GetHotelManager(ctx, id); err != nil || got != managerID {
+		t.Fatalf("hotel manager: got %d, %v", got, err)
+	}
+}
+
+func testCreateHotelUnknownTag(t *testing.T, b Backend) {
+	ctx := context.Background()
+	s := b.Storage
+	managerID := b.AddUser(t, manager("m1"))
+	createCity(t, s, "Almaty")
+	createTag(t, s, "wifi")
+
+	hotel := models.Hotel{Name: "Hotel", ManagerId: managerID}
+	_, err := s.CreateHotel(ctx, hotel, "Almaty", []string{"wifi", "spa"})
+	if !errors.Is(err, postgresql.ErrorTagNotExists) {
+		t.Fatalf("unknown tag: got %v, want ErrorTagNotExists", err)
+	}
+	if hotels := allHotels(t, s); len(hotels) != 0 {
+		t.Fatalf("failed hotel was kept: %+v", hotels)
+	}
+	// the name is free again, so nothing of the failed hotel is left
+	createHotel(t, s, managerID, "Hotel", "Almaty", "wifi")
+}
+
+func testManagerFiltering(t *testing.T, b Backend) {
+	ctx := context.Background()
+	s := b.Storage
+	first := b.AddUser(t, manager("m1"))
+	second := b.AddUser(t, manager("m2"))
+	createCity(t, s, "Almaty")
+	createTag(t, s, "wifi")
+	createTag(t, s, "pool")
+
+	createHotel(t, s, first, "A", "Almaty", "wifi", "pool")
+	createHotel(t, s, first, "B", "Almaty")
+	createHotel(t, s, second, "C", "Almaty", "wifi")
+
+	hotels, err := s.GetHotelsByManager(ctx, first)
+	if err != nil {
+		t.Fatalf("getting manager hotels: %v", err)
+	}
+	if names := hotelNames(hotels); !sameNames(names, []string{"A", "B"}) {
+		t.Fatalf("first manager hotels: got %v", names)
+	}
+	for _, hotel := range hotels {
+		if hotel.Name == "A" && !sameNames(tagNames(hotel.Tags), []string{"wifi", "pool"}) {
+			t.Fatalf("hotel rows were not grouped: %+v", hotel)
+		}
+	}
+
+	hotels, err = s.GetHotelsByManager(ctx, second+100)
+	if err != nil || len(hotels) != 0 {
+		t.Fatalf("unknown manager hotels: got %v, %v", hotels, err)
+	}
+	if names := hotelNames(allHotels(t, s)); !sameNames(names, []string{"A", "B", "C"}) {
+		t.Fatalf("all hotels: got %v", names)
+	}
+}
+
+func testUpdateHotel(t *testing.T, b Backend) {
+	ctx := context.Background()
+	s := b.Storage
+	managerID := b.AddUser(t, manager("m1"))
+	createCity(t, s, "Almaty")
+	createCity(t, s, "Astana")
+	createTag(t, s, "wifi")
+	createTag(t, s, "pool")
+	createTag(t, s, "spa")
+	id := createHotel(t, s, managerID, "A", "Almaty", "wifi", "pool")
+	createHotel(t, s, managerID, "B", "Almaty")
+
+	name, city := "A2", "Astana"
+	tags := []string{"pool", "spa"}
+	if err := s.UpdateHotel(ctx, id, models.HotelUpdate{Name: &name, City: &city, Tags: &tags}); err != nil {
+		t.Fatalf("updating hotel: %v", err)
+	}
+	hotel := getHotel(t, s, id)
+	if hotel.Name != "A2" || hotel.City.Name != "Astana" || !sameNames(tagNames(hotel.Tags), tags) {
+		t.Fatalf("updated hotel: got %+v", hotel)
+	}
+
+	// a failed update changes nothing
+	taken := "B"
+	if err := s.UpdateHotel(ctx, id, models.HotelUpdate{Name: &taken}); !errors.Is(err, postgresql.ErrorExists) {
+		t.Fatalf("taken name: got %v, want ErrorExists", err)
+	}
+	name, unknown := "A3", []string{"wifi", "sauna"}
+	err := s.UpdateHotel(ctx, id, models.HotelUpdate{Name: &name, Tags: &unknown})
+	if !errors.Is(err, postgresql.ErrorTagNotExists) {
+		t.Fatalf("unknown tag: got %v, want ErrorTagNotExists", err)
+	}
+	unknownCity := "Atlantis"
+	if err := s.UpdateHotel(ctx, id, models.HotelUpdate{City: &unknownCity}); !errors.Is(err, postgresql.ErrorCityNotExists) {
+		t.Fatalf("unknown city: got %v, want ErrorCityNotExists", err)
+	}
+	hotel = getHotel(t, s, id)
+	if hotel.Name != "A2" || hotel.City.Name != "Astana" || !sameNames(tagNames(hotel.Tags), tags) {
+		t.Fatalf("failed updates changed the hotel: got %+v", hotel)
+	}
+
+	if err := s.UpdateHotel(ctx, id+100, models.HotelUpdate{Name: &name}); !errors.Is(err, postgresql.ErrorNotExists) {
+		t.Fatalf("unknown hotel: got %v, want ErrorNotExists", err)
+	}
+}
+
+func testDeleteHotel(t *testing.T, b Backend) {
+	ctx := context.Background()
+	s := b.Storage
+	managerID := b.AddUser(t, manager("m1"))
+	guestID := b.AddUser(t, models.User{Username: "guest"})
+	createCity(t, s, "Almaty")
+	id := createHotel(t, s, managerID, "A", "Almaty")
+	kept := createHotel(t, s, managerID, "B", "Almaty")
+	categoryID := createCategory(t, s, id, "Standard", 100, 2)
+	createRoom(t, s, id, categoryID, "1")
+	booking, err := s.CreateBooking(ctx, stay(guestID, date(2025, 1, 10), date(2025, 1, 12)), categoryID)
+	if err != nil {
+		t.Fatalf("booking: %v", err)
+	}
+
+	if err := s.DeleteHotel(ctx, id); err != nil {
+		t.Fatalf("deleting hotel: %v", err)
+	}
+	if err := s.DeleteHotel(ctx, id); !errors.Is(err, postgresql.ErrorNotExists) {
+		t.Fatalf("deleting hotel twice: got %v, want ErrorNotExists", err)
+	}
+	if _, err := s.GetHotel(ctx, id); !errors.Is(err, postgresql.ErrorNotExists) {
+		t.Fatalf("getting deleted hotel: got %v, want ErrorNotExists", err)
+	}
+	if _, err := s.GetHotelManager(ctx, id); !errors.Is(err, postgresql.ErrorNotExists) {
+		t.Fatalf("managing deleted hotel: got %v, want ErrorNotExists", err)
+	}
+	name := "A2"
+	if err := s.UpdateHotel(ctx, id, models.HotelUpdate{Name: &name}); !errors.Is(err, postgresql.ErrorNotExists) {
+		t.Fatalf("updating deleted hotel: got %v, want ErrorNotExists", err)
+	}
+	// deleted hotels disappear from the listings
+	if hotels := allHotels(t, s); len(hotels) != 1 || hotels[0].Id != kept {
+		t.Fatalf("listing after delete: got %v", hotelNames(hotels))
+	}
+	hotels, err := s.GetHotelsByManager(ctx, managerID)
+	if err != nil || len(hotels) != 1 || hotels[0].Id != kept {
+		t.Fatalf("listing own hotels after delete: got %v, %v", hotelNames(hotels), err)
+	}
+	// but keep their booking history
+	if got, err := s.GetBooking(ctx, booking.Id); err != nil || got.HotelId != id {
+		t.Fatalf("booking of deleted hotel: got %+v, %v", got, err)
+	}
+}
+
+func testTransferHotel(t *testing.T, b Backend) {
+	ctx := context.Background()
+	s := b.Storage
+	from := b.AddUser(t, manager("m1"))
+	to := b.AddUser(t, manager("m2"))
+	admin := b.AddUser(t, models.User{Username: "admin", Role: models.RoleAdmin})
+	createCity(t, s, "Almaty")
+	id := createHotel(t, s, from, "A", "Almaty")
+
+	_, err := s.TransferHotel(ctx, models.HotelTransfer{HotelId: id + 100, ToManagerId: to, ActorId: admin})
+	if !errors.Is(err, postgresql.ErrorNotExists) {
+		t.Fatalf("unknown hotel: got %v, want ErrorNotExists", err)
+	}
+	_, err = s.TransferHotel(ctx, models.HotelTransfer{HotelId: id, ToManagerId: admin + 100, ActorId: admin})
+	if !errors.Is(err, postgresql.ErrorUserNotExists) {
+		t.Fatalf("unknown manager: got %v, want ErrorUserNotExists", err)
+	}
+
+	client := b.AddUser(t, models.User{Username: "client"})
+	_, err = s.TransferHotel(ctx, models.HotelTransfer{HotelId: id, ToManagerId: client, ActorId: admin})
+	if !errors.Is(err, postgresql.ErrorNotManager) {
+		t.Fatalf("client as manager: got %v, want ErrorNotManager", err)
+	}
+	if got, _ := s.GetHotelManager(ctx, id); got != from {
+		t.Fatalf("rejected transfer changed the manager to %d", got)
+	}
+
+	transfer, err := s.TransferHotel(ctx, models.HotelTransfer{HotelId: id, ToManagerId: to, ActorId: admin})
+	if err != nil {
+		t.Fatalf("transferring hotel: %v", err)
+	}
+	if transfer.Id == 0 || transfer.FromManagerId != from || transfer.ToManagerId != to || transfer.CreatedAt.IsZero() {
+		t.Fatalf("transfer: got %+v", transfer)
+	}
+	if got, _ := s.GetHotelManager(ctx, id); got != to {
+		t.Fatalf("hotel manager after transfer: got %d, want %d", got, to)
+	}
+	if hotels, _ := s.GetHotelsByManager(ctx, from); len(hotels) != 0 {
+		t.Fatalf("previous manager still has the hotel: %+v", hotels)
+	}
+
+	transfers, err := s.ListHotelTransfers(ctx, id)
+	if err != nil {
+		t.Fatalf("listing transfers: %v", err)
+	}
+	if len(transfers) != 1 || transfers[0].Id != transfer.Id || transfers[0].ActorId != admin {
+		t.Fatalf("transfers: got %+v", transfers)
+	}
+}
+
+func testRoomCategories(t *testing.T, b Backend) {
+	ctx := context.Background()
+	s := b.Storage
+	managerID := b.AddUser(t, manager("m1"))
+	createCity(t, s, "Almaty")
+	first := createHotel(t, s, managerID, "A", "Almaty")
+	second := createHotel(t, s, managerID, "B", "Almaty")
+
+	id := createCategory(t, s, first, "Standard", 100, 2)
+	createCategory(t, s, first, "Suite", 300, 4)
+	createCategory(t, s, second, "Standard", 90, 2)
+	_, err := s.CreateRoomCategory(ctx, models.RoomCategory{HotelId: first, Name: "Standard", Price: 1, Capacity: 1})
+	if !errors.Is(err, postgresql.ErrorExists) {
+		t.Fatalf("duplicate category in one hotel: got %v, want ErrorExists", err)
+	}
+
+	categories, err := s.ListRoomCategories(ctx, first)
+	if err != nil || len(categories) != 2 {
+		t.Fatalf("hotel categories: got %+v, %v", categories, err)
+	}
+
+	category, err := s.GetRoomCategory(ctx, id)
+	if err != nil {
+		t.Fatalf("getting category: %v", err)
+	}
+	category.Name = "Suite"
+	if err := s.UpdateRoomCategory(ctx, *category); !errors.Is(err, postgresql.ErrorExists) {
+		t.Fatalf("renaming to a taken name: got %v, want ErrorExists", err)
+	}
+	category.Name, category.Price = "Standard", 120
+	if err := s.UpdateRoomCategory(ctx, *category); err != nil {
+		t.Fatalf("updating category: %v", err)
+	}
+	if got, _ := s.GetRoomCategory(ctx, id); got == nil || got.Price != 120 {
+		t.Fatalf("updated category: got %+v", got)
+	}
+	category.HotelId = second
+	if err := s.UpdateRoomCategory(ctx, *category); !errors.Is(err, postgresql.ErrorNotExists) {
+		t.Fatalf("category of another hotel: got %v, want ErrorNotExists", err)
+	}
+
+	createRoom(t, s, first, id, "101")
+	if err := s.DeleteRoomCategory(ctx, first, id); !errors.Is(err, postgresql.ErrorInUse) {
+		t.Fatalf("deleting category with rooms: got %v, want ErrorInUse", err)
+	}
+	if err := s.DeleteRoomCategory(ctx, second, id); !errors.Is(err, postgresql.ErrorNotExists) {
+		t.Fatalf("deleting category of another hotel: got %v, want ErrorNotExists", err)
+	}
+	if _, err := s.GetRoomCategory(ctx, id+100); !errors.Is(err, postgresql.ErrorNotExists) {
+		t.Fatalf("unknown category: got %v, want ErrorNotExists", err)
+	}
+}
+
+func testRooms(t *testing.T, b Backend) {
+	ctx := context.Background()
+	s := b.Storage
+	managerID := b.AddUser(t, manager("m1"))
+	guestID := b.AddUser(t, models.User{Username: "guest"})
+	createCity(t, s, "Almaty")
+	hotelID := createHotel(t, s, managerID, "A", "Almaty")
+	otherHotelID := createHotel(t, s, managerID, "B", "Almaty")
+	standard := createCategory(t, s, hotelID, "Standard", 100, 2)
+	suite := createCategory(t, s, hotelID, "Suite", 300, 4)
+	other := createCategory(t, s, otherHotelID, "Standard", 100, 2)
+
+	booked := createRoom(t, s, hotelID, standard, "10")
+	unused := createRoom(t, s, hotelID, standard, "9")
+	createRoom(t, s, hotelID, standard, "101")
+	// numbers are unique per hotel, not per category
+	if _, err := s.CreateRoom(ctx, hotelID, models.Room{Number: "10", CategoryId: suite}); !errors.Is(err, postgresql.ErrorExists) {
+		t.Fatalf("duplicate room number: got %v, want ErrorExists", err)
+	}
+	createRoom(t, s, otherHotelID, other, "10")
+
+	rooms, err := s.ListRooms(ctx, standard)
+	if err != nil {
+		t.Fatalf("listing rooms: %v", err)
+	}
+	var numbers []string
+	for _, room := range rooms {
+		numbers = append(numbers, room.Number)
+	}
+	if !slices.Equal(numbers, []string{"9", "10", "101"}) {
+		t.Fatalf("rooms should be in natural order: got %v", numbers)
+	}
+
+	booking := stay(guestID, date(2025, 1, 10), date(2025, 1, 12))
+	for _, room := range rooms {
+		if room.Id != booked {
+			if _, err := s.DeleteRoom(ctx, standard, room.Id); err != nil {
+				t.Fatalf("deleting room: %v", err)
+			}
+		}
+	}
+	if _, err := s.CreateBooking(ctx, booking, standard); err != nil {
+		t.Fatalf("booking: %v", err)
+	}
+
+	retired, err := s.DeleteRoom(ctx, standard, booked)
+	if err != nil || !retired {
+		t.Fatalf("deleting booked room: got %v, %v, want retired", retired, err)
+	}
+	room, err := s.GetRoom(ctx, booked)
+	if err != nil || room.Number != "10" || room.CategoryId != standard || room.Active {
+		t.Fatalf("getting retired room: got %+v, %v", room, err)
+	}
+	if _, err := s.GetRoom(ctx, unused); !errors.Is(err, postgresql.ErrorNotExists) {
+		t.Fatalf("getting removed room: got %v, want ErrorNotExists", err)
+	}
+	if _, err := s.DeleteRoom(ctx, standard, unused); !errors.Is(err, postgresql.ErrorNotExists) {
+		t.Fatalf("deleting removed room: got %v, want ErrorNotExists", err)
+	}
+	rooms, _ = s.ListRooms(ctx, standard)
+	if len(rooms) != 1 || rooms[0].Active {
+		t.Fatalf("booked room should stay retired: %+v", rooms)
+	}
+	if _, err := s.CreateBooking(ctx, stay(guestID, date(2025, 2, 1), date(2025, 2, 2)), standard); !errors.Is(err, postgresql.ErrorNoFreeRoom) {
+		t.Fatalf("booking a retired room: got %v, want ErrorNoFreeRoom", err)
+	}
+}
+
+func testBookings(t *testing.T, b Backend) {
+	ctx := context.Background()
+	s := b.Storage
+	managerID := b.AddUser(t, manager("m1"))
+	guestID := b.AddUser(t, models.User{Username: "guest"})
+	createCity(t, s, "Almaty")
+	hotelID := createHotel(t, s, managerID, "A", "Almaty")
+	categoryID := createCategory(t, s, hotelID, "Standard", 100, 2)
+	first := createRoom(t, s, hotelID, categoryID, "1")
+	second := createRoom(t, s, hotelID, categoryID, "2")
+
+	booking, err := s.CreateBooking(ctx, stay(guestID, date(2025, 1, 10), date(2025, 1, 12)), categoryID)
+	if err != nil {
+		t.Fatalf("booking: %v", err)
+	}
+	if booking.Id == 0 || booking.RoomId != first && booking.RoomId != second {
+		t.Fatalf("booking: got %+v", booking)
+	}
+	overlap, err := s.CreateBooking(ctx, stay(guestID, date(2025, 1, 11), date(2025, 1, 13)), categoryID)
+	if err != nil {
+		t.Fatalf("booking the second room: %v", err)
+	}
+	if overlap.RoomId == booking.RoomId {
+		t.Fatalf("overlapping stays got the same room %d", overlap.RoomId)
+	}
+	if _, err := s.CreateBooking(ctx, stay(guestID, date(2025, 1, 11), date(2025, 1, 12)), categoryID); !errors.Is(err, postgresql.ErrorNoFreeRoom) {
+		t.Fatalf("booking a full category: got %v, want ErrorNoFreeRoom", err)
+	}
+	// the leave date is free for the next guest
+	if _, err := s.CreateBooking(ctx, stay(guestID, date(2025, 1, 12), date(2025, 1, 14)), categoryID); err != nil {
+		t.Fatalf("booking from a leave date: %v", err)
+	}
+
+	got, err := s.GetBooking(ctx, booking.Id)
+	if err != nil {
+		t.Fatalf("getting booking: %v", err)
+	}
+	if got.HotelId != hotelID || got.RoomId != booking.RoomId || got.UserId != guestID || got.Status != models.BookingCreated {
+		t.Fatalf("booking: got %+v", got)
+	}
+	if _, err := s.GetBooking(ctx, booking.Id+100); !errors.Is(err, postgresql.ErrorNotExists) {
+		t.Fatalf("unknown booking: got %v, want ErrorNotExists", err)
+	}
+
+	if err := s.UpdateBookingStatus(ctx, booking.Id, models.BookingCreated, models.BookingCancelled); err != nil {
+		t.Fatalf("cancelling booking: %v", err)
+	}
+	err = s.UpdateBookingStatus(ctx, booking.Id, models.BookingCreated, models.BookingSubmitted)
+	if !errors.Is(err, postgresql.ErrorStatusChanged) {
+		t.Fatalf("stale status: got %v, want ErrorStatusChanged", err)
+	}
+	// a cancelled booking frees its room
+	if _, err := s.CreateBooking(ctx, stay(guestID, date(2025, 1, 10), date(2025, 1, 11)), categoryID); err != nil {
+		t.Fatalf("booking after cancel: %v", err)
+	}
+}
+
+func testSearchHotels(t *testing.T, b Backend) {
+	ctx := context.Background()
+	s := b.Storage
+	managerID := b.AddUser(t, manager("m1"))
+	createCity(t, s, "Almaty")
+	createCity(t, s, "Astana")
+	createTag(t, s, "wifi")
+	createTag(t, s, "pool")
+	lake := createHotel(t, s, managerID, "Lake", "Almaty", "wifi", "pool")
+	park := createHotel(t, s, managerID, "Park", "Almaty", "wifi")
+	createHotel(t, s, managerID, "River", "Astana", "pool")
+	closed := createHotel(t, s, managerID, "Closed", "Almaty", "wifi")
+	createCategory(t, s, lake, "Standard", 200, 2)
+	createCategory(t, s, park, "Standard", 100, 2)
+	createCategory(t, s, park, "Family", 300, 5)
+	if err := s.DeleteHotel(ctx, closed); err != nil {
+		t.Fatalf("deleting hotel: %v", err)
+	}
+
+	search := func(filter models.HotelFilter) []string {
+		t.Helper()
+		if filter.Sort == "" {
+			filter.Sort = "id"
+		}
+		if filter.Limit == 0 {
+			filter.Limit = 10
+		}
+		hotels, _, err := s.SearchHotels(ctx, filter)
+		if err != nil {
+			t.Fatalf("searching %+v: %v", filter, err)
+		}
+		return hotelNames(hotels)
+	}
+
+	checks := []struct {
+		filter models.HotelFilter
+		want []string
+	}{
+		{models.HotelFilter{}, []string{"Lake", "Park", "River"}},
+		{models.HotelFilter{City: "Almaty"}, []string{"Lake", "Park"}},
+		{models.HotelFilter{Name: "AR"}, []string{"Park"}},
+		{models.HotelFilter{Tags: []string{"pool"}}, []string{"Lake", "River"}},
+		{models.HotelFilter{Tags: []string{"wifi", "pool"}}, []string{"Lake", "Park", "River"}},
+		{models.HotelFilter{Tags: []string{"wifi", "pool"}, AllTags: true}, []string{"Lake"}},
+		{models.HotelFilter{PriceTo: 150}, []string{"Park"}},
+		{models.HotelFilter{PriceTo: 150, Capacity: 4}, nil},
+		{models.HotelFilter{Capacity: 4}, []string{"Park"}},
+		{models.HotelFilter{Sort: "price"}, []string{"Park", "Lake", "River"}},
+		{models.HotelFilter{Sort: "name", Desc: true}, []string{"River", "Park", "Lake"}},
+	}
+	for _, check := range checks {
+		if got := search(check.filter); !slices.Equal(got, check.want) {
+			t.Errorf("search %+v: got %v, want %v", check.filter, got, check.want)
+		}
+	}
+
+	for _, sort := range []string{"id", "name", "price"} {
+		var names []string
+		filter := models.HotelFilter{Sort: sort, Limit: 2}
+		for page := 0; page < 3; page++ {
+			hotels, next, err := s.SearchHotels(ctx, filter)
+			if err != nil {
+				t.Fatalf("searching page %d by %s: %v", page, sort, err)
+			}
+			names = append(names, hotelNames(hotels)...)
+			if next == nil {
+				break
+			}
+			filter.Cursor = next
+		}
+		if !sameNames(names, []string{"Lake", "Park", "River"}) || len(names) != 3 {
+			t.Errorf("paging by %s: got %v", sort, names)
+		}
+	}
+}
+
+func testSearchAvailability(t *testing.T, b Backend) {
+	ctx := context.Background()
+	s := b.Storage
+	managerID := b.AddUser(t, manager("m1"))
+	guestID := b.AddUser(t, models.User{Username: "guest"})
+	createCity(t, s, "Almaty")
+	createCity(t, s, "Astana")
+	lake := createHotel(t, s, managerID, "Lake", "Almaty")
+	river := createHotel(t, s, managerID, "River", "Astana")
+	standard := createCategory(t, s, lake, "Standard", 100, 2)
+	family := createCategory(t, s, lake, "Family", 300, 5)
+	createCategory(t, s, lake, "Empty", 50, 2)
+	riverStandard := createCategory(t, s, river, "Standard", 80, 2)
+	createRoom(t, s, lake, standard, "1")
+	createRoom(t, s, lake, standard, "2")
+	createRoom(t, s, lake, family, "3")
+	createRoom(t, s, river, riverStandard, "1")
+
+	from, to := date(2025, 3, 1), date(2025, 3, 4)
+	if _, err := s.CreateBooking(ctx, stay(guestID, date(2025, 3, 3), date(2025, 3, 5)), standard); err != nil {
+		t.Fatalf("booking: %v", err)
+	}
+
+	hotels, err := s.SearchAvailability(ctx, models.AvailabilityFilter{City: "Almaty", From: from, To: to, Guests: 2})
+	if err != nil {
+		t.Fatalf("searching availability: %v", err)
+	}
+	if len(hotels) != 1 || hotels[0].Hotel.Id != lake || len(hotels[0].Categories) != 2 {
+		t.Fatalf("availability: got %+v", hotels)
+	}
+	first, second := hotels[0].Categories[0], hotels[0].Categories[1]
+	if first.Category.Id != standard || first.FreeRooms != 1 || second.Category.Id != family || second.FreeRooms != 1 {
+		t.Fatalf("categories should be cheapest first with free rooms counted: got %+v", hotels[0].Categories)
+	}
+
+	hotels, err = s.SearchAvailability(ctx, models.AvailabilityFilter{HotelId: river, From: from, To: to, Guests: 3})
+	if err != nil || len(hotels) != 0 {
+		t.Fatalf("too many guests: got %+v, %v", hotels, err)
+	}
+	hotels, err = s.SearchAvailability(ctx, models.AvailabilityFilter{HotelId: river, From: from, To: to, Guests: 1})
+	if err != nil || len(hotels) != 1 || hotels[0].Hotel.City.Name != "Astana" {
+		t.Fatalf("availability by hotel: got %+v, %v", hotels, err)
+	}
+}
+
+func testUsers(t *testing.T, b Backend) {
+	ctx := context.Background()
+	s := b.Storage
+	alice := b.AddUser(t, models.User{Username: "alice", FirstName: "Alice", LastName: "Smith", Email: "alice@example.com"})
+	birthday := date(1990, 5, 17)
+	bob := b.AddUser(t, models.User{Username: "bob", FirstName: "Bob", LastName: "Stone", Email: "bob@example.com", Role: models.RoleManager, Birthday: birthday})
+
+	// users registered through the auth service have no birthday
+	user, err := s.GetUser(ctx, alice)
+	if err != nil || user.Username != "alice" || user.Role != models.RoleClient || user.Password != "" || !user.Birthday.IsZero() {
+		t.Fatalf("getting user: got %+v, %v", user, err)
+	}
+	user, err = s.GetUserByUsername(ctx, "bob")
+	if err != nil || user.Id != bob || !user.Birthday.Equal(birthday) {
+		t.Fatalf("getting user by username: got %+v, %v", user, err)
+	}
+	if _, err := s.GetUser(ctx, bob+100); !errors.Is(err, postgresql.ErrorUserNotExists) {
+		t.Fatalf("unknown user: got %v, want ErrorUserNotExists", err)
+	}
+	if _, err := s.GetUserByUsername(ctx, "carol"); !errors.Is(err, postgresql.ErrorUserNotExists) {
+		t.Fatalf("unknown username: got %v, want ErrorUserNotExists", err)
+	}
+
+	update := *user
+	update.FirstName, update.Email, update.Birthday = "Robert", "robert@example.com", time.Time{}
+	if err := s.UpdateUserProfile(ctx, update); err != nil {
+		t.Fatalf("updating profile: %v", err)
+	}
+	user, err = s.GetUser(ctx, bob)
+	if err != nil || user.FirstName != "Robert" || user.LastName != "Stone" || user.Email != "robert@example.com" ||
+		!user.Birthday.IsZero() || user.Role != models.RoleManager {
+		t.Fatalf("updated profile: got %+v, %v", user, err)
+	}
+	update.FirstName, update.Email, update.Birthday = "Bob", "bob@example.com", birthday
+	if err := s.UpdateUserProfile(ctx, update); err != nil {
+		t.Fatalf("restoring profile: %v", err)
+	}
+	update.Id = bob + 100
+	if err := s.UpdateUserProfile(ctx, update); !errors.Is(err, postgresql.ErrorUserNotExists) {
+		t.Fatalf("updating unknown user: got %v, want ErrorUserNotExists", err)
+	}
+
+	if err := s.SetUserBlocked(ctx, bob, true); err != nil {
+		t.Fatalf("blocking user: %v", err)
+	}
+	if blocked, err := s.IsUserBlocked(ctx, bob); err != nil || !blocked {
+		t.Fatalf("blocked user: got %v, %v", blocked, err)
+	}
+	if blocked, err := s.IsUserBlocked(ctx, bob+100); err != nil || blocked {
+		t.Fatalf("unknown user should not be blocked: got %v, %v", blocked, err)
+	}
+	if err := s.SetUserBlocked(ctx, bob+100, true); !errors.Is(err, postgresql.ErrorUserNotExists) {
+		t.Fatalf("blocking unknown user: got %v, want ErrorUserNotExists", err)
+	}
+
+	blocked := true
+	list := func(filter models.UserFilter) []int64 {
+		t.Helper()
+		if filter.Limit == 0 {
+			filter.Limit = 10
+		}
+		users, err := s.ListUsers(ctx, filter)
+		if err != nil {
+			t.Fatalf("listing users: %v", err)
+		}
+		var ids []int64
+		for _, user := range users {
+			ids = append(ids, user.Id)
+		}
+		return ids
+	}
+	checks := []struct {
+		filter models.UserFilter
+		want []int64
+	}{
+		{models.UserFilter{}, []int64{alice, bob}},
+		{models.UserFilter{Query: "SMITH"}, []int64{alice}},
+		{models.UserFilter{Query: "bob@"}, []int64{bob}},
+		{models.UserFilter{Role: models.RoleManager}, []int64{bob}},
+		{models.UserFilter{Blocked: &blocked}, []int64{bob}},
+		{models.UserFilter{Limit: 1, Offset: 1}, []int64{bob}},
+	}
+	for _, check := range checks {
+		if got := list(check.filter); !slices.Equal(got, check.want) {
+			t.Errorf("list %+v: got %v, want %v", check.filter, got, check.want)
+		}
+	}
+}
+
+func testAudit(t *testing.T, b Backend) {
+	ctx := context.Background()
+	s := b.Storage
+	actor := b.AddUser(t, models.User{Username: "admin", Role: models.RoleAdmin})
+
+	entries := []models.AuditEntry{
+		{ActorId: actor, Action: models.AuditCreate, EntityType: models.EntityTag, EntityId: 1, After: json.RawMessage(`{"name":"wifi"}`)},
+		{Action: models.AuditCreate, EntityType: models.EntityCity, EntityId: 1, After: json.RawMessage(`{"name":"Almaty"}`)},
+		{ActorId: actor, Action: models.AuditDelete, EntityType: models.EntityTag, EntityId: 1, Before: json.RawMessage(`{"name":"wifi"}`)},
+	}
+	for _, entry := range entries {
+		if err := s.RecordAudit(ctx, entry); err != nil {
+			t.Fatalf("recording audit: %v", err)
+		}
+	}
+
+	got, err := s.ListAudit(ctx, models.AuditFilter{Limit: 10})
+	if err != nil {
+		t.Fatalf("listing audit: %v", err)
+	}
+	if len(got) != 3 || got[0].Action != models.AuditDelete || got[2].EntityType != models.EntityTag {
+		t.Fatalf("audit should be newest first: got %+v", got)
+	}
+	var before map[string]string
+	if err := json.Unmarshal(got[0].Before, &before); err != nil || before["name"] != "wifi" || got[0].After != nil {
+		t.Fatalf("audit state: got before %s, after %s", got[0].Before, got[0].After)
+	}
+
+	got, err = s.ListAudit(ctx, models.AuditFilter{ActorId: actor, EntityType: models.EntityTag, Limit: 1, Offset: 1})
+	if err != nil || len(got) != 1 || got[0].Action != models.AuditCreate {
+		t.Fatalf("filtered audit: got %+v, %v", got, err)
+	}
+	future := time.Now().Add(time.Hour)
+	got, err = s.ListAudit(ctx, models.AuditFilter{From: &future, Limit: 10})
+	if err != nil || len(got) != 0 {
+		t.Fatalf("audit from the future: got %+v, %v", got, err)
+	}
+}
+
+func manager(username string) models.User {
+	return models.User{Username: username, Role: models.RoleManager}
+}
+
+func testWithTx(t *testing.T, b Backend) {
+	ctx := context.Background()
+	s := b.Storage
+	errFailed := errors.New("failed")
+	userID := b.AddUser(t, models.User{Username: "alice"})
+
+	err := s.WithTx(ctx, func(ctx context.Context) error {
+		id, err := s.CreateTag(ctx, models.Tag{Name: "rolled back"})
+		if err != nil {
+			return err
+		}
+		// the transaction sees its own writes
+		if tag, err := s.GetTag(ctx, id); err != nil || tag.Name != "rolled back" {
+			t.Errorf("reading own write: got %+v, %v", tag, err)
+		}
+		if err := s.SetUserBlocked(ctx, userID, true); err != nil {
+			return err
+		}
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("failed transaction: got %v, want the error of fn", err)
+	}
+	if tags, _ := s.ListTags(ctx); len(tags) != 0 {
+		t.Fatalf("failed transaction left tags: %+v", tags)
+	}
+	if blocked, _ := s.IsUserBlocked(ctx, userID); blocked {
+		t.Fatal("failed transaction left the user blocked")
+	}
+
+	err = s.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := s.CreateTag(ctx, models.Tag{Name: "outer"}); err != nil {
+			return err
+		}
+		err := s.WithTx(ctx, func(ctx context.Context) error {
+			if _, err := s.CreateTag(ctx, models.Tag{Name: "inner"}); err != nil {
+				return err
+			}
+			return errFailed
+		})
+		if !errors.Is(err, errFailed) {
+			t.Errorf("failed nested transaction: got %v, want the error of fn", err)
+		}
+		// a failed nested call does not spoil the outer transaction
+		_, err = s.CreateCity(ctx, models.City{Name: "Almaty"})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("outer transaction: %v", err)
+	}
+	tags, err := s.ListTags(ctx)
+	if err != nil {
+		t.Fatalf("listing tags: %v", err)
+	}
+	if got := tagNames(tags); !sameNames(got, []string{"outer"}) {
+		t.Fatalf("tags after a rolled back savepoint: got %v, want [outer]", got)
+	}
+	if cities, _ := s.ListCities(ctx); len(cities) != 1 {
+		t.Fatalf("cities after a rolled back savepoint: got %+v", cities)
+	}
+
+	// a failing storage call inside fn rolls back the earlier writes
+	err = s.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := s.CreateTag(ctx, models.Tag{Name: "pool"}); err != nil {
+			return err
+		}
+		_, err := s.CreateTag(ctx, models.Tag{Name: "outer"})
+		return err
+	})
+	if !errors.Is(err, postgresql.ErrorExists) {
+		t.Fatalf("duplicate tag in a transaction: got %v, want ErrorExists", err)
+	}
+	tags, _ = s.ListTags(ctx)
+	if got := tagNames(tags); !sameNames(got, []string{"outer"}) {
+		t.Fatalf("tags after a failed storage call: got %v, want [outer]", got)
+	}
+}
+
+func createTag(t *testing.T, s service.HotelStorage, name string) int64 {
+	t.Helper()
+	id, err := s.CreateTag(context.Background(), models.Tag{Name: name})
+	if err != nil {
+		t.Fatalf("creating tag %s: %v", name, err)
+	}
+	return id
+}
+
+func createCity(t *testing.T, s service.HotelStorage, name string) int64 {
+	t.Helper()
+	id, err := s.CreateCity(context.Background(), models.City{Name: name})
+	if err != nil {
+		t.Fatalf("creating city %s: %v", name, err)
+	}
+	return id
+}
+
+func createHotel(t *testing.T, s service.HotelStorage, managerID int64, name, city string, tags ...string) int64 {
+	t.Helper()
+	hotel := models.Hotel{Name: name, Desc: name + " hotel", ManagerId: managerID}
+	id, err := s.CreateHotel(context.Background(), hotel, city, tags)
+	if err != nil {
+		t.Fatalf("creating hotel %s: %v", name, err)
+	}
+	return id
+}
+
+func createCategory(t *testing.T, s service.HotelStorage, hotelID int64, name string, price float64, capacity int64) int64 {
+	t.Helper()
+	category := models.RoomCategory{HotelId: hotelID, Name: name, Price: price, Capacity: capacity, Size: 20}
+	id, err := s.CreateRoomCategory(context.Background(), category)
+	if err != nil {
+		t.Fatalf("creating category %s: %v", name, err)
+	}
+	return id
+}
+
+func createRoom(t *testing.T, s service.HotelStorage, hotelID, categoryID int64, number string) int64 {
+	t.Helper()
+	id, err := s.CreateRoom(context.Background(), hotelID, models.Room{Number: number, CategoryId: categoryID})
+	if err != nil {
+		t.Fatalf("creating room %s: %v", number, err)
+	}
+	return id
+}
+
+func getHotel(t *testing.T, s service.HotelStorage, id int64) *models.Hotel {
+	t.Helper()
+	hotel, err := s.GetHotel(context.Background(), id)
+	if err != nil {
+		t.Fatalf("getting hotel: %v", err)
+	}
+	return hotel
+}
+
+func allHotels(t *testing.T, s service.HotelStorage) []*models.Hotel {
+	t.Helper()
+	hotels, err := s.GetAllHotes(context.Background())
+	if err != nil {
+		t.Fatalf("getting all hotels: %v", err)
+	}
+	return hotels
+}
+
+func stay(userID int64, from, to time.Time) models.Booking {
+	return models.Booking{
+		EntryDate: from,
+		LeaveDate: to,
+		Price: 100,
+		Status: models.BookingCreated,
+		GuestsCount: 1,
+		UserId: userID,
+	}
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func hotelNames(hotels []*models.Hotel) []string {
+	var names []string
+	for _, hotel := range hotels {
+		names = append(names, hotel.Name)
+	}
+	return names
+}
+
+func tagNames(tags []models.Tag) []string {
+	var names []string
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
+
+// sameNames compares names as sets, neither backend promises an order of tags or hotels.
+func sameNames(got, want []string) bool {
+	return slices.Equal(slices.Sorted(slices.Values(got)), slices.Sorted(slices.Values(want)))
+}
@@ -65,13 +65,13 @@ CREATE TABLE IF NOT EXISTS booking(
 
 -- +goose Down
 -- +goose StatementBegin
-DROP TABLE my_user;
-DROP TABLE tag;
-DROP TABLE city;
-DROP TABLE hotel;
-DROP TABLE tag_hotel;
-DROP TABLE room_category;
-DROP TABLE room;
 DROP TABLE booking;
+DROP TABLE room;
+DROP TABLE room_category;
+DROP TABLE tag_hotel;
+DROP TABLE hotel;
+DROP TABLE city;
+DROP TABLE tag;
+DROP TABLE my_user;
 DROP TYPE status_enum;
 -- +goose StatementEnd
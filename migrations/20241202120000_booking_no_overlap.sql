@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+ALTER TABLE booking
+ADD CONSTRAINT booking_dates_check CHECK (leave_date > entry_date);
+
+-- one room can not be booked twice for the same night
+ALTER TABLE booking
+ADD CONSTRAINT booking_no_overlap
+EXCLUDE USING gist (room_id WITH =, daterange(entry_date, leave_date) WITH &&);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE booking
+DROP CONSTRAINT booking_no_overlap;
+
+ALTER TABLE booking
+DROP CONSTRAINT booking_dates_check;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+ALTER TYPE status_enum ADD VALUE IF NOT EXISTS 'checked_in' AFTER 'submitted';
+ALTER TYPE status_enum ADD VALUE IF NOT EXISTS 'cancelled';
+ALTER TYPE status_enum ADD VALUE IF NOT EXISTS 'no_show';
+
+-- +goose Down
+-- +goose StatementBegin
+BEGIN;
+UPDATE booking SET current_status='closed'
+WHERE current_status IN ('checked_in', 'cancelled', 'no_show');
+
+ALTER TYPE status_enum RENAME TO status_enum_old;
+CREATE TYPE status_enum AS ENUM ('created', 'submitted', 'closed');
+ALTER TABLE booking
+ALTER COLUMN current_status TYPE status_enum USING current_status::text::status_enum;
+DROP TYPE status_enum_old;
+COMMIT;
+-- +goose StatementEnd
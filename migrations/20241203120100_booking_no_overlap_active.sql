@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- cancelled and no-show bookings free the room again
+ALTER TABLE booking
+DROP CONSTRAINT booking_no_overlap;
+
+ALTER TABLE booking
+ADD CONSTRAINT booking_no_overlap
+EXCLUDE USING gist (room_id WITH =, daterange(entry_date, leave_date) WITH &&)
+WHERE (current_status NOT IN ('cancelled', 'no_show'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE booking
+DROP CONSTRAINT booking_no_overlap;
+
+ALTER TABLE booking
+ADD CONSTRAINT booking_no_overlap
+EXCLUDE USING gist (room_id WITH =, daterange(entry_date, leave_date) WITH &&);
+-- +goose StatementEnd
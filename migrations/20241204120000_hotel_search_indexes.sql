@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS hotel_name_trgm_idx ON hotel USING gin (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS hotel_name_id_idx ON hotel (name, id);
+CREATE INDEX IF NOT EXISTS hotel_city_id_idx ON hotel (city_id);
+CREATE INDEX IF NOT EXISTS tag_hotel_hotel_id_idx ON tag_hotel (hotel_id, tag_id);
+CREATE INDEX IF NOT EXISTS room_category_hotel_price_idx ON room_category (hotel_id, price);
+CREATE INDEX IF NOT EXISTS room_category_hotel_capacity_idx ON room_category (hotel_id, сapacity);
+CREATE INDEX IF NOT EXISTS room_category_fk_idx ON room (category_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS room_category_fk_idx;
+DROP INDEX IF EXISTS room_category_hotel_capacity_idx;
+DROP INDEX IF EXISTS room_category_hotel_price_idx;
+DROP INDEX IF EXISTS tag_hotel_hotel_id_idx;
+DROP INDEX IF EXISTS hotel_city_id_idx;
+DROP INDEX IF EXISTS hotel_name_id_idx;
+DROP INDEX IF EXISTS hotel_name_trgm_idx;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE hotel
+ADD COLUMN active BOOLEAN NOT NULL DEFAULT TRUE,
+ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE hotel
+DROP COLUMN active,
+DROP COLUMN created_at;
+-- +goose StatementEnd
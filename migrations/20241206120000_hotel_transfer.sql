@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS hotel_transfer(
+    id SERIAL PRIMARY KEY,
+    hotel_id INT REFERENCES hotel (id) NOT NULL,
+    from_manager_id INT REFERENCES my_user (id),
+    to_manager_id INT REFERENCES my_user (id) NOT NULL,
+    actor_id INT REFERENCES my_user (id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS hotel_transfer_hotel_id_idx ON hotel_transfer (hotel_id, created_at);
+CREATE INDEX IF NOT EXISTS hotel_manager_id_idx ON hotel (manager_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS hotel_manager_id_idx;
+DROP TABLE hotel_transfer;
+-- +goose StatementEnd
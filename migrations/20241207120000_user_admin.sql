@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- role is owned by booking_auth, it is added here for databases created from these migrations only
+ALTER TABLE my_user ADD COLUMN IF NOT EXISTS role VARCHAR(50) NOT NULL DEFAULT 'client';
+ALTER TABLE my_user ADD COLUMN IF NOT EXISTS blocked BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS user_audit(
+    id SERIAL PRIMARY KEY,
+    user_id INT REFERENCES my_user (id) NOT NULL,
+    actor_id INT REFERENCES my_user (id),
+    action VARCHAR(50) NOT NULL,
+    old_value VARCHAR(255),
+    new_value VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS user_audit_user_id_idx ON user_audit (user_id, created_at);
+CREATE INDEX IF NOT EXISTS my_user_username_trgm_idx ON my_user USING gin (username gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS my_user_username_trgm_idx;
+DROP TABLE user_audit;
+-- role stays, booking_auth relies on it
+ALTER TABLE my_user DROP COLUMN IF EXISTS blocked;
+-- +goose StatementEnd
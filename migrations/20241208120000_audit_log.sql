@@ -0,0 +1,60 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS audit_log(
+    id BIGSERIAL PRIMARY KEY,
+    actor_id INT REFERENCES my_user (id),
+    action VARCHAR(50) NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id BIGINT NOT NULL,
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_created_at_idx ON audit_log (created_at);
+CREATE INDEX IF NOT EXISTS audit_log_actor_idx ON audit_log (actor_id, created_at);
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx ON audit_log (entity_type, entity_id, created_at);
+
+-- user changes were kept apart before, the audit log covers them now
+INSERT INTO audit_log(actor_id, action, entity_type, entity_id, before, after, created_at)
+SELECT
+    actor_id,
+    CASE action WHEN 'role_changed' THEN 'change_role' WHEN 'blocked' THEN 'block' ELSE 'unblock' END,
+    'user',
+    user_id,
+    CASE WHEN action='role_changed' THEN jsonb_build_object('role', old_value) ELSE jsonb_build_object('blocked', action='unblocked') END,
+    CASE WHEN action='role_changed' THEN jsonb_build_object('role', new_value) ELSE jsonb_build_object('blocked', action='blocked') END,
+    created_at
+FROM user_audit ORDER BY id;
+
+DROP TABLE user_audit;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS user_audit(
+    id SERIAL PRIMARY KEY,
+    user_id INT REFERENCES my_user (id) NOT NULL,
+    actor_id INT REFERENCES my_user (id),
+    action VARCHAR(50) NOT NULL,
+    old_value VARCHAR(255),
+    new_value VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS user_audit_user_id_idx ON user_audit (user_id, created_at);
+
+INSERT INTO user_audit(user_id, actor_id, action, old_value, new_value, created_at)
+SELECT
+    entity_id,
+    actor_id,
+    CASE action WHEN 'change_role' THEN 'role_changed' WHEN 'block' THEN 'blocked' ELSE 'unblocked' END,
+    before->>'role',
+    after->>'role',
+    created_at
+FROM audit_log
+WHERE entity_type='user' AND action IN ('change_role', 'block', 'unblock')
+ORDER BY id;
+
+DROP TABLE audit_log;
+-- +goose StatementEnd
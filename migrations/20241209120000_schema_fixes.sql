@@ -0,0 +1,84 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the first letter of the column was a cyrillic "с"
+ALTER TABLE room_category RENAME COLUMN сapacity TO capacity;
+
+ALTER TABLE room
+ALTER COLUMN number TYPE VARCHAR(50) USING number::text;
+
+-- names were only checked with a select before the insert, which races, so duplicates
+-- are cleaned up first. Tags and cities with the same name are merged into the oldest one.
+UPDATE tag_hotel AS th SET tag_id=oldest.id
+FROM tag AS t, (SELECT name, min(id) AS id FROM tag GROUP BY name) AS oldest
+WHERE th.tag_id=t.id AND t.name=oldest.name AND t.id<>oldest.id;
+DELETE FROM tag AS t
+USING tag AS oldest
+WHERE t.name=oldest.name AND t.id>oldest.id;
+
+UPDATE hotel AS h SET city_id=oldest.id
+FROM city AS c, (SELECT name, min(id) AS id FROM city GROUP BY name) AS oldest
+WHERE h.city_id=c.id AND c.name=oldest.name AND c.id<>oldest.id;
+DELETE FROM city AS c
+USING city AS oldest
+WHERE c.name=oldest.name AND c.id>oldest.id;
+
+-- hotels and categories have their own rooms and bookings, newer duplicates get their id
+-- appended to the name instead
+UPDATE hotel AS h SET name=h.name || ' (' || h.id || ')'
+WHERE EXISTS (SELECT 1 FROM hotel AS oldest WHERE oldest.name=h.name AND oldest.id<h.id);
+UPDATE room_category AS rc SET name=rc.name || ' (' || rc.id || ')'
+WHERE EXISTS (
+    SELECT 1 FROM room_category AS oldest
+    WHERE oldest.hotel_id=rc.hotel_id AND oldest.name=rc.name AND oldest.id<rc.id
+);
+
+ALTER TABLE tag ADD CONSTRAINT tag_name_key UNIQUE (name);
+ALTER TABLE city ADD CONSTRAINT city_name_key UNIQUE (name);
+ALTER TABLE hotel ADD CONSTRAINT hotel_name_key UNIQUE (name);
+ALTER TABLE room_category ADD CONSTRAINT room_category_hotel_name_key UNIQUE (hotel_id, name);
+
+-- drop links to missing tags and repeated tags of one hotel before they are forbidden,
+-- merging tags above may have repeated them
+DELETE FROM tag_hotel WHERE hotel_id IS NULL OR tag_id IS NULL;
+DELETE FROM tag_hotel AS th
+USING tag_hotel AS other
+WHERE th.hotel_id=other.hotel_id AND th.tag_id=other.tag_id AND th.id>other.id;
+
+ALTER TABLE tag_hotel
+ALTER COLUMN hotel_id SET NOT NULL,
+ALTER COLUMN tag_id SET NOT NULL,
+DROP CONSTRAINT tag_hotel_hotel_id_fkey,
+DROP CONSTRAINT tag_hotel_tag_id_fkey,
+ADD CONSTRAINT tag_hotel_hotel_id_fkey FOREIGN KEY (hotel_id) REFERENCES hotel (id) ON DELETE CASCADE,
+ADD CONSTRAINT tag_hotel_tag_id_fkey FOREIGN KEY (tag_id) REFERENCES tag (id) ON DELETE CASCADE,
+ADD CONSTRAINT tag_hotel_hotel_tag_key UNIQUE (hotel_id, tag_id);
+
+-- the unique constraint index covers it
+DROP INDEX IF EXISTS tag_hotel_hotel_id_idx;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- merged and renamed duplicates stay as they are
+CREATE INDEX IF NOT EXISTS tag_hotel_hotel_id_idx ON tag_hotel (hotel_id, tag_id);
+
+ALTER TABLE tag_hotel
+DROP CONSTRAINT tag_hotel_hotel_tag_key,
+DROP CONSTRAINT tag_hotel_hotel_id_fkey,
+DROP CONSTRAINT tag_hotel_tag_id_fkey,
+ADD CONSTRAINT tag_hotel_hotel_id_fkey FOREIGN KEY (hotel_id) REFERENCES hotel (id),
+ADD CONSTRAINT tag_hotel_tag_id_fkey FOREIGN KEY (tag_id) REFERENCES tag (id),
+ALTER COLUMN hotel_id DROP NOT NULL,
+ALTER COLUMN tag_id DROP NOT NULL;
+
+ALTER TABLE room_category DROP CONSTRAINT room_category_hotel_name_key;
+ALTER TABLE hotel DROP CONSTRAINT hotel_name_key;
+ALTER TABLE city DROP CONSTRAINT city_name_key;
+ALTER TABLE tag DROP CONSTRAINT tag_name_key;
+
+-- fails for room numbers that are not integers
+ALTER TABLE room
+ALTER COLUMN number TYPE INT USING number::int;
+
+ALTER TABLE room_category RENAME COLUMN capacity TO сapacity;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the auth service registers users without a birthday
+ALTER TABLE my_user ALTER COLUMN birthday DROP NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- fails while users without a birthday exist
+ALTER TABLE my_user ALTER COLUMN birthday SET NOT NULL;
+-- +goose StatementEnd
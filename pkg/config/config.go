@@ -12,8 +12,11 @@ import (
 
 type Config struct {
 	Env string `yaml:"env" env-default:"dev"`
+	// Storage is "postgres" or "memory", the memory storage starts empty and is lost on exit
+	Storage string `yaml:"storage" env-default:"postgres"`
 	HttpServer `yaml:"http_server"`
 	GrpcServer `yaml:"grpc_auth_server"`
+	Database `yaml:"database"`
 }
 
 type HttpServer struct {
@@ -24,6 +27,33 @@ type HttpServer struct {
 
 type GrpcServer struct {
 	GrpcAuthAddress string `yaml:"auth_address" env-default:"localhost:8000"`
+	UserCacheTTL time.Duration `yaml:"user_cache_ttl" env-default:"30s"`
+	UserCacheSize int `yaml:"user_cache_size" env-default:"10000"`
+	// AuthMode "jwt" verifies tokens locally with the keys from JWTKeyFile instead of asking the auth service
+	AuthMode string `yaml:"auth_mode" env-default:"grpc"`
+	// JWTKeyFile holds PEM public keys, a JWKS document or the HMAC secret of the auth service
+	JWTKeyFile string `yaml:"jwt_key_file"`
+	JWTKeyReload time.Duration `yaml:"jwt_key_reload" env-default:"1m"`
+	AuthTimeout time.Duration `yaml:"timeout" env-default:"2s"`
+	AuthRetries int `yaml:"retries" env-default:"2"`
+	AuthRetryBackoff time.Duration `yaml:"retry_backoff" env-default:"100ms"`
+	BreakerThreshold int `yaml:"breaker_threshold" env-default:"5"`
+	BreakerCooldown time.Duration `yaml:"breaker_cooldown" env-default:"10s"`
+	// TLS dials the auth service over tls, a client cert and key turn on mtls
+	TLS bool `yaml:"tls"`
+	TLSCAFile string `yaml:"tls_ca_file"`
+	TLSCertFile string `yaml:"tls_cert_file"`
+	TLSKeyFile string `yaml:"tls_key_file"`
+	TLSServerName string `yaml:"tls_server_name"`
+	// zero keeps keepalive pings off, grpc servers reject pings more often than their enforcement policy allows (5m by default)
+	KeepaliveTime time.Duration `yaml:"keepalive_time"`
+	KeepaliveTimeout time.Duration `yaml:"keepalive_timeout" env-default:"10s"`
+	MaxMessageSize int `yaml:"max_message_size" env-default:"4194304"`
+}
+
+type Database struct {
+	// AutoMigrate applies pending migrations on startup, otherwise they are run with the migrate command
+	AutoMigrate bool `yaml:"auto_migrate"`
 }
 
 func NewConfig() *Config {